@@ -2,40 +2,82 @@ package goboilerplate
 
 import (
 	"context"
+	"math/rand"
+	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
-// ExpiryDuration is used to define the expiry duration when caching some data.
-type ExpiryDuration uint8
+// ExpiryDuration is the name of an expiry tier, used to define the expiry duration when caching some data.
+// Besides DurationShort and DurationLong, tiers could be defined freely through ExpiryConf.
+type ExpiryDuration string
 
 const (
 	// DurationShort is used for caching some data that might change in
 	// a short time.
 	// It could also be used when we're not able to invalidate the cache.
-	DurationShort ExpiryDuration = iota
+	DurationShort ExpiryDuration = "short"
 
 	// DurationLong is typically used for caching some data that we own.
 	// This way, we could invalidate the cache any time the data changes.
-	DurationLong
+	DurationLong ExpiryDuration = "long"
+
+	// DurationNegative is used for caching that some data doesn't exist.
+	DurationNegative ExpiryDuration = "negative"
 )
 
 // Default duration for cache expiry
 const (
-	defaultDurationShort = 1 * time.Minute
-	defaultDurationLong  = 1 * time.Hour
+	defaultDurationShort    = 1 * time.Minute
+	defaultDurationLong     = 1 * time.Hour
+	defaultDurationNegative = 10 * time.Second
 )
 
+// minJitteredTTL is the lowest TTL after jitter, since a TTL of 0 means no expiry to the storages.
+const minJitteredTTL = time.Millisecond
+
+// Expiry defines the expiry of a tier.
+type Expiry struct {
+	TTL time.Duration
+	// JitterPercent randomly spreads the TTL by up to this percentage in both directions, so that entries written
+	// together don't expire together. The jittered TTL is never below a millisecond.
+	JitterPercent float64
+}
+
 // ExpiryConf defines the configuration for each expiry duration.
-type ExpiryConf map[ExpiryDuration]time.Duration
+type ExpiryConf map[ExpiryDuration]Expiry
 
 // Set the default duration if it's not already set.
 func (c ExpiryConf) Set() {
-	if _, ok := c[DurationShort]; !ok {
-		c[DurationShort] = defaultDurationShort
+	if c[DurationShort].TTL <= 0 {
+		c[DurationShort] = Expiry{TTL: defaultDurationShort}
+	}
+	if c[DurationLong].TTL <= 0 {
+		c[DurationLong] = Expiry{TTL: defaultDurationLong}
+	}
+	if c[DurationNegative].TTL <= 0 {
+		c[DurationNegative] = Expiry{TTL: defaultDurationNegative}
+	}
+}
+
+// TTL returns the TTL of the expiry duration with its jitter applied.
+// Unknown expiry durations fall back to DurationShort.
+func (c ExpiryConf) TTL(d ExpiryDuration) time.Duration {
+	expiry, ok := c[d]
+	if !ok {
+		expiry = c[DurationShort]
+	}
+
+	if expiry.JitterPercent <= 0 {
+		return expiry.TTL
 	}
-	if _, ok := c[DurationLong]; !ok {
-		c[DurationLong] = defaultDurationLong
+
+	jitter := float64(expiry.TTL) * expiry.JitterPercent / 100 * (2*rand.Float64() - 1)
+	if ttl := expiry.TTL + time.Duration(jitter); ttl > minJitteredTTL {
+		return ttl
 	}
+	return minJitteredTTL
 }
 
 // Cacher is the interface of a data cacher.
@@ -45,3 +87,177 @@ type Cacher interface {
 	Del(ctx context.Context, key string) error
 	Flush(ctx context.Context) error
 }
+
+// KeyValue is a single entry in a batch operation.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// BatchResult is the result of a single key in a batch operation.
+type BatchResult struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+// BatchCacher is the interface of a data cacher supporting multi-key operations.
+// The results are in the same order as the requested keys, and a missing key has ErrNotFound as its Err.
+type BatchCacher interface {
+	Cacher
+	MGet(ctx context.Context, keys ...string) []BatchResult
+	MSet(ctx context.Context, items []KeyValue, expiration ExpiryDuration) []BatchResult
+	MDel(ctx context.Context, keys ...string) []BatchResult
+}
+
+// MGet gets multiple keys from the cacher, falling back to one Get per key if it's not a BatchCacher.
+func MGet(ctx context.Context, c Cacher, keys ...string) []BatchResult {
+	if bc, ok := c.(BatchCacher); ok {
+		return bc.MGet(ctx, keys...)
+	}
+
+	results := make([]BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+		results[i].Value, results[i].Err = c.Get(ctx, key)
+	}
+	return results
+}
+
+// MSet sets multiple keys to the cacher, falling back to one Set per key if it's not a BatchCacher.
+func MSet(ctx context.Context, c Cacher, items []KeyValue, expiration ExpiryDuration) []BatchResult {
+	if bc, ok := c.(BatchCacher); ok {
+		return bc.MSet(ctx, items, expiration)
+	}
+
+	results := make([]BatchResult, len(items))
+	for i, item := range items {
+		results[i].Key = item.Key
+		results[i].Value = item.Value
+		results[i].Err = c.Set(ctx, item.Key, item.Value, expiration)
+	}
+	return results
+}
+
+// MDel deletes multiple keys from the cacher, falling back to one Del per key if it's not a BatchCacher.
+func MDel(ctx context.Context, c Cacher, keys ...string) []BatchResult {
+	if bc, ok := c.(BatchCacher); ok {
+		return bc.MDel(ctx, keys...)
+	}
+
+	results := make([]BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+		results[i].Err = c.Del(ctx, key)
+	}
+	return results
+}
+
+// FlushProgressFunc is called periodically while flushing, with the number of keys deleted so far.
+type FlushProgressFunc func(deleted int64)
+
+// ProgressFlusher is the interface of a data cacher able to report its progress while flushing.
+type ProgressFlusher interface {
+	FlushWithProgress(ctx context.Context, progress FlushProgressFunc) (deleted int64, err error)
+}
+
+// FlushWithProgress flushes the cacher, falling back to Flush without progress if it's not a ProgressFlusher.
+func FlushWithProgress(ctx context.Context, c Cacher, progress FlushProgressFunc) (deleted int64, err error) {
+	if pf, ok := c.(ProgressFlusher); ok {
+		return pf.FlushWithProgress(ctx, progress)
+	}
+
+	err = c.Flush(ctx)
+	return
+}
+
+// TagCacher is the interface of a data cacher able to invalidate entries by tag, e.g. every entry derived from
+// "author:42".
+type TagCacher interface {
+	Cacher
+	SetWithTags(ctx context.Context, key string, value string, expiration ExpiryDuration, tags ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+// SetWithTags sets the tagged value to the cacher, returning ErrNotSupported if it's not a TagCacher.
+func SetWithTags(
+	ctx context.Context,
+	c Cacher,
+	key string,
+	value string,
+	expiration ExpiryDuration,
+	tags ...string,
+) error {
+	tc, ok := c.(TagCacher)
+	if !ok {
+		return errors.Wrap(ErrNotSupported, "tags")
+	}
+	return tc.SetWithTags(ctx, key, value, expiration, tags...)
+}
+
+// InvalidateTags invalidates the tags of the cacher, returning ErrNotSupported if it's not a TagCacher.
+func InvalidateTags(ctx context.Context, c Cacher, tags ...string) error {
+	tc, ok := c.(TagCacher)
+	if !ok {
+		return errors.Wrap(ErrNotSupported, "tags")
+	}
+	return tc.InvalidateTags(ctx, tags...)
+}
+
+// NegativeCacher is the interface of a data cacher able to remember that some data doesn't exist.
+type NegativeCacher interface {
+	Cacher
+	// SetNotFound caches the key as known to be missing with DurationNegative, making Get return ErrNegativeCached.
+	SetNotFound(ctx context.Context, key string) error
+}
+
+// NamespaceCacher is the interface of a data cacher able to invalidate every entry of a namespace at once, the
+// namespace of a key being the part before its first ':', e.g. "article" for "article:42".
+type NamespaceCacher interface {
+	Cacher
+	// BumpNamespace moves the namespace to a new version, leaving the entries of the previous one unreachable.
+	BumpNamespace(ctx context.Context, namespace string) (version int64, err error)
+}
+
+// BumpNamespace bumps the namespace of the cacher, returning ErrNotSupported if it's not a NamespaceCacher.
+func BumpNamespace(ctx context.Context, c Cacher, namespace string) (version int64, err error) {
+	nc, ok := c.(NamespaceCacher)
+	if !ok {
+		err = errors.Wrap(ErrNotSupported, "namespaces")
+		return
+	}
+	return nc.BumpNamespace(ctx, namespace)
+}
+
+// KeyNamespace returns the namespace of key, the part before its first ':'. ok is false when key has none.
+func KeyNamespace(key string) (namespace string, ok bool) {
+	namespace, _, ok = strings.Cut(key, ":")
+	return namespace, ok && namespace != ""
+}
+
+// CacheKey describes a key stored by a data cacher.
+type CacheKey struct {
+	// Key is the key used by the application, e.g. "article:42".
+	Key string
+	// StorageKey is the key in the storage, with the key prefix and the namespace version if any.
+	StorageKey string
+	// TTL is the remaining time to live, 0 if the key never expires.
+	TTL time.Duration
+}
+
+// CacheUsage is the memory used by a group of keys, e.g. a namespace.
+type CacheUsage struct {
+	Group string
+	Keys  int64
+	Bytes int64
+}
+
+// CacheInspector is the interface of a data cacher whose keys could be inspected, for administration purpose.
+type CacheInspector interface {
+	// Inspect describes key, or returns ErrNotFound.
+	Inspect(ctx context.Context, key string) (CacheKey, error)
+	// Keys returns at most limit keys matching the glob pattern, e.g. "article:*".
+	Keys(ctx context.Context, pattern string, limit int) ([]CacheKey, error)
+	// MemoryUsage returns the memory used by the keys, grouped by namespace and by kind of internal key.
+	MemoryUsage(ctx context.Context) ([]CacheUsage, error)
+}
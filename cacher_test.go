@@ -0,0 +1,73 @@
+package goboilerplate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+func TestExpiryConf(t *testing.T) {
+	conf := goboilerplate.ExpiryConf{
+		goboilerplate.DurationLong: {TTL: 0},
+		"medium":                   {TTL: 10 * time.Minute},
+		"jittered":                 {TTL: 10 * time.Minute, JitterPercent: 10},
+		"fully jittered":           {TTL: 10 * time.Millisecond, JitterPercent: 100},
+	}
+	conf.Set()
+
+	t.Run("defaults", func(t *testing.T) {
+		require.Equal(t, time.Minute, conf.TTL(goboilerplate.DurationShort))
+		require.Equal(t, time.Hour, conf.TTL(goboilerplate.DurationLong))
+	})
+
+	t.Run("named tier", func(t *testing.T) {
+		require.Equal(t, 10*time.Minute, conf.TTL("medium"))
+	})
+
+	t.Run("unknown tier falls back to short", func(t *testing.T) {
+		require.Equal(t, time.Minute, conf.TTL("unknown"))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		ttls := make(map[time.Duration]struct{})
+		for i := 0; i < 100; i++ {
+			ttl := conf.TTL("jittered")
+			require.GreaterOrEqual(t, ttl, 9*time.Minute)
+			require.LessOrEqual(t, ttl, 11*time.Minute)
+			ttls[ttl] = struct{}{}
+		}
+		require.Greater(t, len(ttls), 1)
+	})
+
+	t.Run("jittered ttl never reaches 0", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			require.GreaterOrEqual(t, conf.TTL("fully jittered"), time.Millisecond)
+		}
+	})
+}
+
+func TestKeyNamespace(t *testing.T) {
+	tests := map[string]struct {
+		key               string
+		expectedNamespace string
+		expectedOK        bool
+	}{
+		"namespaced":      {key: "article:42", expectedNamespace: "article", expectedOK: true},
+		"nested":          {key: "article:42:comments", expectedNamespace: "article", expectedOK: true},
+		"without":         {key: "article"},
+		"empty namespace": {key: ":42"},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			namespace, ok := goboilerplate.KeyNamespace(test.key)
+			require.Equal(t, test.expectedOK, ok)
+			if ok {
+				require.Equal(t, test.expectedNamespace, namespace)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+var (
+	cacheCMD = &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and administrate the cache.",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			initConfig()
+			initRedisClient()
+			initCache()
+		},
+	}
+
+	cacheGetCMD = &cobra.Command{
+		Use:   "get <key>",
+		Short: "Get the value and the TTL of a key.",
+		Args:  cobra.ExactArgs(1),
+		Run:   runCacheGet,
+	}
+
+	cacheKeysCMD = &cobra.Command{
+		Use:   "keys [pattern]",
+		Short: "List the keys matching a glob pattern, e.g. 'article:*'.",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runCacheKeys,
+	}
+
+	cacheDelCMD = &cobra.Command{
+		Use:   "del <key>...",
+		Short: "Delete keys.",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runCacheDel,
+	}
+
+	cacheBumpCMD = &cobra.Command{
+		Use:   "bump <namespace>...",
+		Short: "Invalidate every key of namespaces.",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runCacheBump,
+	}
+
+	cacheUsageCMD = &cobra.Command{
+		Use:   "usage",
+		Short: "Show the memory used per namespace.",
+		Args:  cobra.NoArgs,
+		Run:   runCacheUsage,
+	}
+
+	expiryConf goboilerplate.ExpiryConf
+	cacher     goboilerplate.Cacher
+	cacheAdmin *cache.Admin
+)
+
+func init() {
+	cacheKeysCMD.Flags().Int("limit", 100, "Maximum number of keys to list, 0 for all of them.")
+
+	cacheCMD.AddCommand(cacheGetCMD, cacheKeysCMD, cacheDelCMD, cacheBumpCMD, cacheUsageCMD)
+	rootCMD.AddCommand(cacheCMD)
+}
+
+// initCache builds the cacher used by the application, on top of redis.
+func initCache() {
+	expiryConf = goboilerplate.ExpiryConf{
+		goboilerplate.DurationShort: {TTL: config.Redis.ShortExpirationTime, JitterPercent: config.Cache.ExpiryJitterPercent},
+		goboilerplate.DurationLong:  {TTL: config.Redis.LongExpirationTime, JitterPercent: config.Cache.ExpiryJitterPercent},
+	}
+	for name, tier := range config.Cache.ExpiryTiers {
+		expiryConf[goboilerplate.ExpiryDuration(name)] = goboilerplate.Expiry{
+			TTL:           tier.TTL,
+			JitterPercent: tier.JitterPercent,
+		}
+	}
+
+	// the typed nil would be a non-nil interface, so fall back to the global providers explicitly
+	cacheMeterProvider := otelMeterProvider()
+	var cacheTracerProvider oteltrace.TracerProvider
+	if tracerProvider != nil {
+		cacheTracerProvider = tracerProvider
+	}
+
+	storage := redis.NewNamespacedRedisCacher(redisClient, expiryConf, app, config.Cache.NamespaceVersionCacheTTL)
+	cacher = storage
+
+	compression, err := cache.ParseCompression(config.Cache.Compression.Algorithm)
+	if err != nil {
+		logrus.Fatalf("Error parsing cache compression: %+v", err)
+	}
+	cacher, err = cache.NewEncodingCacher(cacher, cache.EncodingConf{
+		Compression:          compression,
+		CompressionThreshold: config.Cache.Compression.ThresholdBytes,
+		Keyring: cache.Keyring{
+			Current: config.Cache.Encryption.CurrentKeyVersion,
+			Keys:    config.Cache.Encryption.Keys,
+		},
+	})
+	if err != nil {
+		logrus.Fatalf("Error initializing cache encoding: %+v", err)
+	}
+
+	if config.Cache.Breaker.Enabled {
+		cacher = cache.NewBreakerCacher(cacher, cache.BreakerConf{
+			Name:             "redis",
+			FailureThreshold: config.Cache.Breaker.FailureThreshold,
+			SlowThreshold:    config.Cache.Breaker.SlowThreshold,
+			Timeout:          config.Cache.Breaker.Timeout,
+			ProbeInterval:    config.Cache.Breaker.ProbeInterval,
+			MeterProvider:    cacheMeterProvider,
+		}, func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+	if config.Cache.Local.Enabled {
+		localCacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{
+			goboilerplate.DurationShort: {TTL: config.Cache.Local.ExpirationTime},
+			goboilerplate.DurationLong:  {TTL: config.Cache.Local.ExpirationTime},
+		}, config.Cache.Local.MaxEntries, config.Cache.Local.MaxBytes)
+
+		cacher, err = redis.NewTieredCacher(context.Background(), redisClient, localCacher, cacher, app+"###invalidation")
+		if err != nil {
+			logrus.Fatalf("Error initializing tiered cacher: %+v", err)
+		}
+	}
+
+	cacher = cache.NewInstrumentedCacher(cacher, cacheMeterProvider, cacheTracerProvider)
+
+	cacher = cache.NewNegativeCacher(cacher)
+
+	cacheAdmin = cache.NewAdmin(cacher, storage.(cache.AdminStorage))
+}
+
+func runCacheGet(cmd *cobra.Command, args []string) {
+	info, err := cacheAdmin.Get(cmd.Context(), args[0])
+	if err != nil {
+		logrus.Fatalf("Error getting '%s': %+v", args[0], err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "key:\t%s\n", info.Key)
+	fmt.Fprintf(w, "storage key:\t%s\n", info.StorageKey)
+	fmt.Fprintf(w, "ttl:\t%s\n", formatTTL(info.TTL))
+	if info.Negative {
+		fmt.Fprintf(w, "value:\t(cached as not found)\n")
+	} else {
+		fmt.Fprintf(w, "value:\t%s\n", info.Value)
+	}
+	w.Flush()
+}
+
+func runCacheKeys(cmd *cobra.Command, args []string) {
+	pattern := "*"
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		logrus.Fatalf("Error getting flag 'limit': %+v", err)
+	}
+
+	keys, err := cacheAdmin.Keys(cmd.Context(), pattern, limit)
+	if err != nil {
+		logrus.Fatalf("Error listing keys matching '%s': %+v", pattern, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTTL\tSTORAGE KEY")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key.Key, formatTTL(key.TTL), key.StorageKey)
+	}
+	w.Flush()
+}
+
+func runCacheDel(cmd *cobra.Command, args []string) {
+	for _, key := range args {
+		if err := cacheAdmin.Del(cmd.Context(), key); err != nil {
+			logrus.Errorf("Error deleting '%s': %+v", key, err)
+			continue
+		}
+		fmt.Printf("deleted '%s'\n", key)
+	}
+}
+
+func runCacheBump(cmd *cobra.Command, args []string) {
+	for _, namespace := range args {
+		version, err := cacheAdmin.BumpNamespace(cmd.Context(), namespace)
+		if err != nil {
+			logrus.Fatalf("Error bumping namespace '%s': %+v", namespace, err)
+		}
+		fmt.Printf("bumped '%s' to version %d\n", namespace, version)
+	}
+}
+
+func runCacheUsage(cmd *cobra.Command, args []string) {
+	usage, err := cacheAdmin.MemoryUsage(cmd.Context())
+	if err != nil {
+		logrus.Fatalf("Error getting memory usage: %+v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "GROUP\tKEYS\tBYTES\t")
+	for _, group := range usage {
+		fmt.Fprintf(w, "%s\t%d\t%d\t\n", group.Group, group.Keys, group.Bytes)
+	}
+	w.Flush()
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl == 0 {
+		return "none"
+	}
+	return ttl.Round(time.Millisecond).String()
+}
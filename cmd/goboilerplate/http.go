@@ -16,6 +16,7 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
 	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
 	handler "github.com/kurio/boilerplate-go/internal/http"
 	"github.com/kurio/boilerplate-go/internal/redis"
 )
@@ -39,11 +40,27 @@ func initHTTPApp() {
 	initRedisClient()
 	initHTTPClient()
 
-	expiryConf := goboilerplate.ExpiryConf{
-		goboilerplate.DurationShort: config.Redis.ShortExpirationTime,
-		goboilerplate.DurationLong:  config.Redis.LongExpirationTime,
+	initCache()
+
+	// a fencing token counter per loaded key would never be deleted
+	locker := redis.NewRedisUnfencedLocker(redisClient, app, config.Redis.CommandTimeout)
+	loader := cache.NewLoader(cacher, locker, config.Cache.LoadLockTimeout)
+	if config.Cache.Refresh.Enabled {
+		softExpiryConf := goboilerplate.ExpiryConf{}
+		for expiration, expiry := range expiryConf {
+			softExpiryConf[expiration] = goboilerplate.Expiry{
+				TTL:           time.Duration(float64(expiry.TTL) * config.Cache.Refresh.SoftExpirationRatio),
+				JitterPercent: expiry.JitterPercent,
+			}
+		}
+		loader = cache.NewRefreshingLoader(
+			cacher,
+			locker,
+			config.Cache.LoadLockTimeout,
+			softExpiryConf,
+			config.Cache.Refresh.Beta,
+		)
 	}
-	cacher := redis.NewRedisCacher(redisClient, expiryConf, app)
 
 	// initService()
 
@@ -58,29 +75,44 @@ func initHTTPApp() {
 	e.Server.ReadTimeout = config.HTTP.Server.ReadTimeout
 	e.Server.WriteTimeout = config.HTTP.Server.WriteTimeout
 
-	e.HTTPErrorHandler = handler.ErrorHandler
+	// the client IP is rate limited, so it must not be spoofable through the headers
+	if len(config.HTTP.Server.TrustedProxies) > 0 {
+		trustOptions := []echo.TrustOption{
+			echo.TrustLoopback(false),
+			echo.TrustLinkLocal(false),
+			echo.TrustPrivateNet(false),
+		}
+		for _, ipRange := range config.HTTP.Server.TrustedProxies {
+			trustOptions = append(trustOptions, echo.TrustIPRange(ipRange))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trustOptions...)
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
+	e.Validator = handler.NewValidator()
+	e.Binder = handler.NewBinder()
+	redaction := handler.DefaultRedactionPolicy()
+	redaction.DeniedHeaders = append(redaction.DeniedHeaders, config.HTTP.Redaction.DeniedHeaders...)
+	if config.RateLimit.APIKeyHeader != "" {
+		redaction.DeniedHeaders = append(redaction.DeniedHeaders, config.RateLimit.APIKeyHeader)
+	}
+	redaction.AllowedHeaders = config.HTTP.Redaction.AllowedHeaders
+	redaction.ValueMasks = append(redaction.ValueMasks, config.HTTP.Redaction.ValueMasks...)
+
+	e.HTTPErrorHandler = handler.NewErrorHandler(handler.ErrorHandlerConfig{
+		ProblemJSON:        config.HTTP.Server.ProblemJSON,
+		ProblemTypeBaseURI: config.HTTP.Server.ProblemTypeBaseURI,
+		Redaction:          &redaction,
+		MeterProvider:      otelMeterProvider(),
+	})
 
 	/*********
 	Middleware
 	**********/
-	/* uncomment if needed, set to debug, or set skipper
-	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogURI:       true,
-		LogRoutePath: true,
-		LogStatus:    true,
-		LogLatency:   true,
-		LogValuesFunc: func(c echo.Context, values middleware.RequestLoggerValues) error {
-			logrus.WithFields(logrus.Fields{
-				"URI":        values.URI,
-				"status":     values.Status,
-				"latency_ms": fmt.Sprintf("%.3f", values.Latency.Seconds()*1000),
-				"route":      values.RoutePath,
-			}).Info("request info")
-
-			return nil
-		},
-	}))
-	*/
+	if config.HTTP.Server.LogRequests {
+		e.Use(handler.RequestLoggerMiddleware(handler.URLSkipper, redaction))
+	}
 
 	e.Use(otelecho.Middleware(app, otelecho.WithSkipper(handler.URLSkipper)))
 
@@ -89,6 +121,29 @@ func initHTTPApp() {
 
 	e.Use(handler.TimeoutMiddleware(config.HTTP.Server.Timeout))
 
+	if config.RateLimit.Enabled {
+		rules := make([]handler.RateLimitRule, len(config.RateLimit.Rules))
+		for i, rule := range config.RateLimit.Rules {
+			rules[i] = handler.RateLimitRule{
+				Name: rule.Name,
+				By:   rule.By,
+				Limit: goboilerplate.RateLimit{
+					Rate:   rule.Rate,
+					Period: rule.Period,
+					Burst:  rule.Burst,
+				},
+				Routes: rule.Routes,
+			}
+		}
+
+		rateLimiter := redis.NewRedisRateLimiter(redisClient, app, config.Redis.CommandTimeout)
+		e.Use(handler.RateLimitMiddleware(rateLimiter, handler.RateLimitConfig{
+			Skipper:      handler.RateLimitSkipper,
+			Rules:        rules,
+			APIKeyHeader: config.RateLimit.APIKeyHeader,
+		}))
+	}
+
 	// Basic handlers...
 	e.GET("/ping", func(c echo.Context) error {
 		return c.String(http.StatusOK, "pong")
@@ -98,7 +153,7 @@ func initHTTPApp() {
 		return context.String(http.StatusOK, gitCommit)
 	}).Name = "version"
 
-	handler.AddSomeHandler(e, cacher)
+	handler.AddSomeHandler(e, loader, cacher, redaction, otelMeterProvider())
 }
 
 func runHTTP(cmd *cobra.Command, args []string) {
@@ -108,6 +163,13 @@ func runHTTP(cmd *cobra.Command, args []string) {
 		logrus.Warn("Adding /debug for profiling")
 		e.GET("/debug/*", echo.WrapHandler(http.DefaultServeMux)).Name = "debug"
 	}
+	switch {
+	case config.Admin.Enabled && config.Debug:
+		logrus.Warn("Adding /admin for cache administration")
+		handler.AddCacheAdminHandler(e.Group("/admin", handler.BearerTokenMiddleware(config.Admin.Token)), cacheAdmin)
+	case config.Admin.Enabled:
+		logrus.Warn("Ignoring admin.enabled, /admin is only added in debug mode")
+	}
 
 	const address = ":7723"
 	go func() {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	otelpkg "go.opentelemetry.io/otel"
+	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
@@ -80,3 +81,12 @@ func initOtel() {
 			resources)
 	}
 }
+
+// otelMeterProvider returns meterProvider, nil rather than a typed nil when it is not initialized, so that the
+// instrumented components fall back to the global one.
+func otelMeterProvider() otelmetric.MeterProvider {
+	if meterProvider == nil {
+		return nil
+	}
+	return meterProvider
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/go-redis/redis/extra/redisotel/v9"
 	"github.com/go-redis/redis/v9"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -130,9 +134,47 @@ func initMongoClient() {
 }
 
 func initRedisClient() {
-	if config.Redis.Cluster {
+	tlsConfig, err := newRedisTLSConfig()
+	if err != nil {
+		logrus.Fatalf("Error initializing redis TLS config: %+v", err)
+	}
+
+	addrs := strings.Split(config.Redis.Address, ",")
+	switch {
+	case config.Redis.Sentinel.MasterName != "":
+		failoverOpts := &redis.FailoverOptions{
+			MasterName:       config.Redis.Sentinel.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelUsername: config.Redis.Sentinel.Username,
+			SentinelPassword: config.Redis.Sentinel.Password,
+			RouteByLatency:   config.Redis.RouteByLatency,
+			RouteRandomly:    config.Redis.RouteRandomly,
+			Username:         config.Redis.Username,
+			Password:         config.Redis.Password,
+			DB:               config.Redis.DB,
+			DialTimeout:      config.Redis.DialTimeout,
+			ReadTimeout:      config.Redis.ReadTimeout,
+			WriteTimeout:     config.Redis.WriteTimeout,
+			PoolSize:         config.Redis.PoolSize,
+			PoolTimeout:      config.Redis.PoolTimeout,
+			ConnMaxIdleTime:  config.Redis.ConnMaxIdleTime,
+			ConnMaxLifetime:  config.Redis.ConnMaxLifetime,
+			TLSConfig:        tlsConfig,
+		}
+		if config.Redis.Sentinel.RouteToReplicas {
+			// routes the read-only commands to the replicas
+			redisClient = redis.NewFailoverClusterClient(failoverOpts)
+		} else {
+			redisClient = redis.NewFailoverClient(failoverOpts)
+		}
+	case config.Redis.Cluster:
 		redisClient = redis.NewClusterClient(&redis.ClusterOptions{
-			Addrs:           strings.Split(config.Redis.Address, ","),
+			Addrs:           addrs,
+			ReadOnly:        config.Redis.ReadOnly,
+			RouteByLatency:  config.Redis.RouteByLatency,
+			RouteRandomly:   config.Redis.RouteRandomly,
+			Username:        config.Redis.Username,
+			Password:        config.Redis.Password,
 			DialTimeout:     config.Redis.DialTimeout,
 			ReadTimeout:     config.Redis.ReadTimeout,
 			WriteTimeout:    config.Redis.WriteTimeout,
@@ -140,10 +182,14 @@ func initRedisClient() {
 			PoolTimeout:     config.Redis.PoolTimeout,
 			ConnMaxIdleTime: config.Redis.ConnMaxIdleTime,
 			ConnMaxLifetime: config.Redis.ConnMaxLifetime,
+			TLSConfig:       tlsConfig,
 		})
-	} else {
+	default:
 		redisClient = redis.NewClient(&redis.Options{
 			Addr:            config.Redis.Address,
+			Username:        config.Redis.Username,
+			Password:        config.Redis.Password,
+			DB:              config.Redis.DB,
 			DialTimeout:     config.Redis.DialTimeout,
 			ReadTimeout:     config.Redis.ReadTimeout,
 			WriteTimeout:    config.Redis.WriteTimeout,
@@ -151,6 +197,7 @@ func initRedisClient() {
 			PoolTimeout:     config.Redis.PoolTimeout,
 			ConnMaxIdleTime: config.Redis.ConnMaxIdleTime,
 			ConnMaxLifetime: config.Redis.ConnMaxLifetime,
+			TLSConfig:       tlsConfig,
 		})
 	}
 
@@ -169,6 +216,45 @@ func initRedisClient() {
 	}
 }
 
+// newRedisTLSConfig returns the TLS config of the redis connections, nil when TLS is disabled.
+func newRedisTLSConfig() (tlsConfig *tls.Config, err error) {
+	if !config.Redis.TLS.Enabled {
+		return
+	}
+
+	tlsConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.Redis.TLS.ServerName,
+		InsecureSkipVerify: config.Redis.TLS.InsecureSkipVerify,
+	}
+
+	if config.Redis.TLS.CAFile != "" {
+		var ca []byte
+		ca, err = os.ReadFile(config.Redis.TLS.CAFile)
+		if err != nil {
+			err = errors.Wrapf(err, "error reading CA file '%s'", config.Redis.TLS.CAFile)
+			return
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			err = errors.Errorf("no certificate found in CA file '%s'", config.Redis.TLS.CAFile)
+			return
+		}
+	}
+
+	if config.Redis.TLS.CertFile != "" {
+		var cert tls.Certificate
+		cert, err = tls.LoadX509KeyPair(config.Redis.TLS.CertFile, config.Redis.TLS.KeyFile)
+		if err != nil {
+			err = errors.Wrap(err, "error loading client certificate")
+			return
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return
+}
+
 func initHTTPClient() {
 	defaultTransport := &http.Transport{
 		MaxIdleConns:        config.HTTP.Client.MaxIdleConns,
@@ -1,14 +1,94 @@
 package goboilerplate
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+// ErrorCode is the stable, machine-readable code of a kind of error.
+type ErrorCode string
+
+// The kinds of error. Unknown errors are CodeInternal.
+const (
+	CodeInternal              ErrorCode = "internal"
+	CodeBadRequest            ErrorCode = "bad_request"
+	CodeUnauthorized          ErrorCode = "unauthorized"
+	CodeForbidden             ErrorCode = "forbidden"
+	CodeNotFound              ErrorCode = "not_found"
+	CodeTimeout               ErrorCode = "timeout"
+	CodeConflict              ErrorCode = "conflict"
+	CodeGone                  ErrorCode = "gone"
+	CodePreconditionFailed    ErrorCode = "precondition_failed"
+	CodeTooManyRequests       ErrorCode = "too_many_requests"
+	CodeDependencyUnavailable ErrorCode = "dependency_unavailable"
+)
+
+// CodedError is the interface of the errors telling their kind.
+type CodedError interface {
+	error
+	Code() ErrorCode
+}
+
+// ClassifyError finds the error telling the kind of err in its chain: a CodedError first, then ErrNotFound, then a
+// context error, see errors.Is and errors.As. It returns the code of its kind along with it, or CodeInternal along
+// with the innermost error when none is found, walking the pkg/errors causes of the errors not implementing Unwrap.
+func ClassifyError(err error) (code ErrorCode, cause error) {
+	var coded CodedError
+	switch {
+	case stderrors.As(err, &coded):
+		return coded.Code(), coded
+	case stderrors.Is(err, ErrNotFound):
+		return CodeNotFound, ErrNotFound
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout, context.DeadlineExceeded
+	case stderrors.Is(err, context.Canceled):
+		return CodeTimeout, context.Canceled
+	}
+
+	code, cause = CodeInternal, err
+	for cause != nil {
+		next := unwrapError(cause)
+		if next == nil {
+			break
+		}
+		cause = next
+	}
+	return
+}
+
+// ErrorCodeOf returns the code of the kind of err, CodeInternal when unknown, see ClassifyError.
+func ErrorCodeOf(err error) ErrorCode {
+	code, _ := ClassifyError(err)
+	return code
+}
+
+// unwrapError returns the error wrapped by err, nil when it wraps none or joins several.
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
 // ErrNotFound is used when entry not found.
 var ErrNotFound = errors.New("not found")
 
+// ErrNegativeCached is used when entry is cached as known to be missing. Its cause is ErrNotFound.
+var ErrNegativeCached = errors.WithMessage(ErrNotFound, "negative cached")
+
+// ErrLockNotAcquired is used when a lock is held by someone else.
+var ErrLockNotAcquired = errors.New("lock not acquired")
+
+// ErrNotSupported is used when a cacher lacks an optional feature, e.g. tags.
+var ErrNotSupported = errors.New("not supported by the cacher")
+
 // ConstraintError is used when a certain constraint is broken, i.e. on validation user input.
 type ConstraintError string
 
@@ -16,11 +96,50 @@ func (e ConstraintError) Error() string {
 	return string(e)
 }
 
+// Code returns CodeBadRequest.
+func (e ConstraintError) Code() ErrorCode {
+	return CodeBadRequest
+}
+
 // ConstraintErrorf creates new interface value of type error
 func ConstraintErrorf(text string, value ...interface{}) ConstraintError {
 	return ConstraintError(fmt.Sprintf(text, value...))
 }
 
+// FieldViolation is a constraint broken by a field of the input.
+type FieldViolation struct {
+	// Field is the path of the field, e.g. "author.emails[0]".
+	Field string
+	// Rule is the name of the broken constraint, e.g. "required" or "max".
+	Rule string
+	// Params are the parameters of the rule, e.g. the maximum of "max".
+	Params  []string
+	Message string
+}
+
+// ValidationError is used when the input breaks some constraints, reporting every broken one at once.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+// NewValidationError constructs ValidationError with the violations.
+func NewValidationError(violations ...FieldViolation) *ValidationError {
+	return &ValidationError{Violations: violations}
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return "invalid input: " + strings.Join(messages, "; ")
+}
+
+// Code returns CodeBadRequest.
+func (e *ValidationError) Code() ErrorCode {
+	return CodeBadRequest
+}
+
 // UnauthorizedError is used when user is not authenticated.
 type UnauthorizedError string
 
@@ -28,7 +147,114 @@ func (e UnauthorizedError) Error() string {
 	return string(e)
 }
 
+// Code returns CodeUnauthorized.
+func (e UnauthorizedError) Code() ErrorCode {
+	return CodeUnauthorized
+}
+
 // UnauthorizedErrorf constructs UnauthorizedError with formatted message.
 func UnauthorizedErrorf(format string, a ...interface{}) UnauthorizedError {
 	return UnauthorizedError(fmt.Sprintf(format, a...))
 }
+
+// ForbiddenError is used when user is authenticated, but not allowed to do the operation.
+type ForbiddenError string
+
+func (e ForbiddenError) Error() string {
+	return string(e)
+}
+
+// Code returns CodeForbidden.
+func (e ForbiddenError) Code() ErrorCode {
+	return CodeForbidden
+}
+
+// ForbiddenErrorf constructs ForbiddenError with formatted message.
+func ForbiddenErrorf(format string, a ...interface{}) ForbiddenError {
+	return ForbiddenError(fmt.Sprintf(format, a...))
+}
+
+// ConflictError is used when the operation conflicts with the current state, e.g. a duplicate.
+type ConflictError string
+
+func (e ConflictError) Error() string {
+	return string(e)
+}
+
+// Code returns CodeConflict.
+func (e ConflictError) Code() ErrorCode {
+	return CodeConflict
+}
+
+// ConflictErrorf constructs ConflictError with formatted message.
+func ConflictErrorf(format string, a ...interface{}) ConflictError {
+	return ConflictError(fmt.Sprintf(format, a...))
+}
+
+// GoneError is used when an entry existed, but has been removed for good.
+type GoneError string
+
+func (e GoneError) Error() string {
+	return string(e)
+}
+
+// Code returns CodeGone.
+func (e GoneError) Code() ErrorCode {
+	return CodeGone
+}
+
+// GoneErrorf constructs GoneError with formatted message.
+func GoneErrorf(format string, a ...interface{}) GoneError {
+	return GoneError(fmt.Sprintf(format, a...))
+}
+
+// PreconditionFailedError is used when a precondition of the operation is not met, e.g. a stale version.
+type PreconditionFailedError string
+
+func (e PreconditionFailedError) Error() string {
+	return string(e)
+}
+
+// Code returns CodePreconditionFailed.
+func (e PreconditionFailedError) Code() ErrorCode {
+	return CodePreconditionFailed
+}
+
+// PreconditionFailedErrorf constructs PreconditionFailedError with formatted message.
+func PreconditionFailedErrorf(format string, a ...interface{}) PreconditionFailedError {
+	return PreconditionFailedError(fmt.Sprintf(format, a...))
+}
+
+// TooManyRequestsError is used when user has sent too many requests.
+type TooManyRequestsError string
+
+func (e TooManyRequestsError) Error() string {
+	return string(e)
+}
+
+// Code returns CodeTooManyRequests.
+func (e TooManyRequestsError) Code() ErrorCode {
+	return CodeTooManyRequests
+}
+
+// TooManyRequestsErrorf constructs TooManyRequestsError with formatted message.
+func TooManyRequestsErrorf(format string, a ...interface{}) TooManyRequestsError {
+	return TooManyRequestsError(fmt.Sprintf(format, a...))
+}
+
+// DependencyUnavailableError is used when a service or a storage we depend on is unavailable.
+type DependencyUnavailableError string
+
+func (e DependencyUnavailableError) Error() string {
+	return string(e)
+}
+
+// Code returns CodeDependencyUnavailable.
+func (e DependencyUnavailableError) Code() ErrorCode {
+	return CodeDependencyUnavailable
+}
+
+// DependencyUnavailableErrorf constructs DependencyUnavailableError with formatted message.
+func DependencyUnavailableErrorf(format string, a ...interface{}) DependencyUnavailableError {
+	return DependencyUnavailableError(fmt.Sprintf(format, a...))
+}
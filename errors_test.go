@@ -0,0 +1,65 @@
+package goboilerplate_test
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+func TestClassifyError(t *testing.T) {
+	unknownErrors := stderrors.Join(errors.New("first"), errors.New("second"))
+
+	tests := map[string]struct {
+		err           error
+		expectedCode  goboilerplate.ErrorCode
+		expectedCause error
+	}{
+		"nil": {
+			expectedCode: goboilerplate.CodeInternal,
+		},
+		"pkg/errors around %w": {
+			err:           errors.Wrap(fmt.Errorf("wrapped: %w", context.Canceled), "outer"),
+			expectedCode:  goboilerplate.CodeTimeout,
+			expectedCause: context.Canceled,
+		},
+		"pkg/errors message": {
+			err:           errors.Wrap(goboilerplate.ErrNegativeCached, "error getting article"),
+			expectedCode:  goboilerplate.CodeNotFound,
+			expectedCause: goboilerplate.ErrNotFound,
+		},
+		"joined": {
+			err: stderrors.Join(
+				errors.New("first"),
+				fmt.Errorf("second: %w", goboilerplate.TooManyRequestsErrorf("slow down")),
+			),
+			expectedCode:  goboilerplate.CodeTooManyRequests,
+			expectedCause: goboilerplate.TooManyRequestsErrorf("slow down"),
+		},
+		"joined without kind": {
+			err:           unknownErrors,
+			expectedCode:  goboilerplate.CodeInternal,
+			expectedCause: unknownErrors,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			code, cause := goboilerplate.ClassifyError(test.err)
+			require.Equal(t, test.expectedCode, code)
+			require.Equal(t, test.expectedCause, cause)
+		})
+	}
+
+	t.Run("innermost cause of unknown errors", func(t *testing.T) {
+		inner := errors.New("unexpected")
+		code, cause := goboilerplate.ClassifyError(fmt.Errorf("outer: %w", errors.Wrap(inner, "middle")))
+		require.Equal(t, goboilerplate.CodeInternal, code)
+		require.Equal(t, inner.Error(), cause.Error())
+	})
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// KeyInfo describes a key of the cache along with its value.
+type KeyInfo struct {
+	goboilerplate.CacheKey
+	Value string
+	// Negative tells that the key is cached as known to be missing.
+	Negative bool
+}
+
+// AdminStorage is the storage administrated through Admin.
+type AdminStorage interface {
+	goboilerplate.CacheInspector
+}
+
+// Admin administrates the cache for the operators, sparing them the knowledge of how the keys are stored.
+type Admin struct {
+	cacher  goboilerplate.Cacher
+	storage AdminStorage
+}
+
+// NewAdmin is a constructor for Admin.
+// cacher is the cacher used by the application, decoding the values and propagating the deletions to the local
+// caches, while storage is the cacher it is built upon.
+func NewAdmin(cacher goboilerplate.Cacher, storage AdminStorage) *Admin {
+	return &Admin{
+		cacher:  cacher,
+		storage: storage,
+	}
+}
+
+// Get describes key along with its decoded value, or returns ErrNotFound. The header of the entries written by a
+// refreshing Loader is stripped from the value.
+func (a *Admin) Get(ctx context.Context, key string) (info KeyInfo, err error) {
+	info.CacheKey, err = a.storage.Inspect(ctx, key)
+	if err != nil {
+		return
+	}
+
+	var raw string
+	raw, err = a.cacher.Get(ctx, key)
+	if errors.Is(err, goboilerplate.ErrNegativeCached) {
+		info.Negative = true
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	info.Value = decodeEntry(raw).value
+	return
+}
+
+// Keys returns at most limit keys matching the glob pattern, e.g. "article:*".
+func (a *Admin) Keys(ctx context.Context, pattern string, limit int) ([]goboilerplate.CacheKey, error) {
+	return a.storage.Keys(ctx, pattern, limit)
+}
+
+// Del deletes key, or returns ErrNotFound.
+func (a *Admin) Del(ctx context.Context, key string) error {
+	return a.cacher.Del(ctx, key)
+}
+
+// BumpNamespace invalidates every key of namespace, flushing the local caches along with it.
+func (a *Admin) BumpNamespace(ctx context.Context, namespace string) (version int64, err error) {
+	return goboilerplate.BumpNamespace(ctx, a.cacher, namespace)
+}
+
+// MemoryUsage returns the memory used by the keys, grouped by namespace and by kind of internal key.
+func (a *Admin) MemoryUsage(ctx context.Context) ([]goboilerplate.CacheUsage, error) {
+	return a.storage.MemoryUsage(ctx)
+}
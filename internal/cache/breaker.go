@@ -0,0 +1,389 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// ErrBreakerOpen is returned by the operations that can not fail open while the breaker is open, e.g. the deletions
+// which would otherwise be silently lost.
+var ErrBreakerOpen = errors.New("cache breaker is open")
+
+// BreakerState is the state of a breaker.
+type BreakerState int64
+
+const (
+	// BreakerClosed lets the calls through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen short-circuits the calls until a probe succeeds.
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	if s == BreakerOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+// ProbeFunc checks whether the cacher backend is healthy again, e.g. with a redis PING.
+type ProbeFunc func(ctx context.Context) error
+
+// BreakerConf is the configuration of a breaker.
+type BreakerConf struct {
+	// Name identifies the breaker in the logs and the cache.breaker.state metric.
+	Name string
+	// FailureThreshold is the number of consecutive failed or slow calls tripping the breaker.
+	FailureThreshold int
+	// SlowThreshold is the duration above which a call counts as failed, but for the flushes and invalidations. Zero
+	// disables it.
+	SlowThreshold time.Duration
+	// Timeout bounds every call but the flushes and invalidations. Zero disables it.
+	Timeout time.Duration
+	// ProbeInterval is the interval between the recovery probes while the breaker is open.
+	ProbeInterval time.Duration
+	// MeterProvider provides the cache.breaker.state metric, the global one when nil.
+	MeterProvider metric.MeterProvider
+}
+
+type breakerCacher struct {
+	cacher goboilerplate.Cacher
+	conf   BreakerConf
+	probe  ProbeFunc
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+}
+
+// NewBreakerCacher is a constructor for a fail-open cacher: once cacher fails or is slow FailureThreshold times in a
+// row, the breaker opens and every Get is a miss and every Set a no-op, without calling cacher. The invalidations,
+// i.e. Del, Flush, InvalidateTags and BumpNamespace, return ErrBreakerOpen instead, since the stale entries would be
+// served once the breaker closes. Calls failing because the caller gave up are not counted. Flush, MDel,
+// InvalidateTags and BumpNamespace, whose duration grows with the keyspace, are neither bounded by Timeout nor
+// counted as slow.
+// While open, probe is called every ProbeInterval in the background and the breaker closes on its first success.
+// If probe is nil, a Get of a probe key is used instead.
+//
+// The returned cacher implements goboilerplate.BatchCacher, goboilerplate.ProgressFlusher, goboilerplate.TagCacher
+// and goboilerplate.NamespaceCacher on top of cacher, failing the same way.
+func NewBreakerCacher(cacher goboilerplate.Cacher, conf BreakerConf, probe ProbeFunc) goboilerplate.Cacher {
+	if conf.FailureThreshold <= 0 {
+		conf.FailureThreshold = 1
+	}
+	if conf.ProbeInterval <= 0 {
+		conf.ProbeInterval = time.Second
+	}
+
+	c := &breakerCacher{
+		cacher: cacher,
+		conf:   conf,
+		probe:  probe,
+	}
+	if c.probe == nil {
+		c.probe = c.probeGet
+	}
+
+	if conf.MeterProvider == nil {
+		conf.MeterProvider = global.MeterProvider()
+	}
+
+	meter := conf.MeterProvider.Meter(instrumentationName)
+	state, err := meter.AsyncInt64().Gauge(
+		"cache.breaker.state",
+		instrument.WithDescription("The state of the cache breaker, 0 when closed and 1 when open."),
+	)
+	if err != nil {
+		logrus.Errorf("Error creating cache.breaker.state gauge: %+v", err)
+		return c
+	}
+
+	err = meter.RegisterCallback([]instrument.Asynchronous{state}, func(ctx context.Context) {
+		state.Observe(ctx, int64(c.State()), attribute.String("name", conf.Name))
+	})
+	if err != nil {
+		logrus.Errorf("Error registering cache.breaker.state callback: %+v", err)
+	}
+	return c
+}
+
+func (c *breakerCacher) probeGet(ctx context.Context) error {
+	_, err := c.cacher.Get(ctx, "breaker:probe")
+	if errors.Is(err, goboilerplate.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// State returns the current state of the breaker.
+func (c *breakerCacher) State() BreakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *breakerCacher) isOpen() bool {
+	return c.State() == BreakerOpen
+}
+
+// call calls fn with the configured timeout, recording its outcome. ErrNotFound is a success, while the failures
+// caused by the caller's ctx being done tell nothing about the backend and are not recorded.
+func (c *breakerCacher) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	return c.do(ctx, true, fn)
+}
+
+// callUnbounded calls fn like call, but neither bounded by the timeout nor checked against the slow threshold, for
+// the scans and invalidations whose duration grows with the keyspace. Only their errors are recorded.
+func (c *breakerCacher) callUnbounded(ctx context.Context, fn func(ctx context.Context) error) error {
+	return c.do(ctx, false, fn)
+}
+
+func (c *breakerCacher) do(ctx context.Context, bounded bool, fn func(ctx context.Context) error) (err error) {
+	callCtx := ctx
+	if bounded && c.conf.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, c.conf.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err = fn(callCtx)
+	elapsed := time.Since(start)
+
+	switch {
+	case err != nil && ctx.Err() != nil && isContextError(err):
+	case err != nil && !errors.Is(err, goboilerplate.ErrNotFound):
+		c.recordFailure(err)
+	case bounded && c.conf.SlowThreshold > 0 && elapsed > c.conf.SlowThreshold:
+		c.recordFailure(errors.Errorf("call took %s", elapsed))
+	default:
+		c.recordSuccess()
+	}
+	return
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (c *breakerCacher) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = 0
+}
+
+func (c *breakerCacher) recordFailure(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == BreakerOpen {
+		return
+	}
+
+	c.failures++
+	if c.failures < c.conf.FailureThreshold {
+		return
+	}
+
+	c.state = BreakerOpen
+	logrus.Warnf("Cache breaker '%s' opened after %d consecutive failures, last: %+v", c.conf.Name, c.failures, err)
+	go c.probeUntilRecovered()
+}
+
+func (c *breakerCacher) probeUntilRecovered() {
+	ticker := time.NewTicker(c.conf.ProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), c.conf.ProbeInterval)
+		err := c.probe(ctx)
+		cancel()
+		if err != nil {
+			logrus.Debugf("Cache breaker '%s' probe failed: %+v", c.conf.Name, err)
+			continue
+		}
+
+		c.mu.Lock()
+		c.state = BreakerClosed
+		c.failures = 0
+		c.mu.Unlock()
+
+		logrus.Infof("Cache breaker '%s' closed", c.conf.Name)
+		return
+	}
+}
+
+func (c *breakerCacher) Get(ctx context.Context, key string) (value string, err error) {
+	if c.isOpen() {
+		err = goboilerplate.ErrNotFound
+		return
+	}
+
+	err = c.call(ctx, func(ctx context.Context) (err error) {
+		value, err = c.cacher.Get(ctx, key)
+		return
+	})
+	return
+}
+
+func (c *breakerCacher) Set(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+) error {
+	if c.isOpen() {
+		return nil
+	}
+
+	return c.call(ctx, func(ctx context.Context) error {
+		return c.cacher.Set(ctx, key, value, expiration)
+	})
+}
+
+func (c *breakerCacher) Del(ctx context.Context, key string) error {
+	if c.isOpen() {
+		return ErrBreakerOpen
+	}
+
+	return c.call(ctx, func(ctx context.Context) error {
+		return c.cacher.Del(ctx, key)
+	})
+}
+
+func (c *breakerCacher) Flush(ctx context.Context) error {
+	if c.isOpen() {
+		return ErrBreakerOpen
+	}
+
+	return c.callUnbounded(ctx, func(ctx context.Context) error {
+		return c.cacher.Flush(ctx)
+	})
+}
+
+// batchErr returns the first error of results other than a miss, so that a batch counts as a single call.
+func batchErr(results []goboilerplate.BatchResult) error {
+	for _, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, goboilerplate.ErrNotFound) {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+func (c *breakerCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	if c.isOpen() {
+		results = make([]goboilerplate.BatchResult, len(keys))
+		for i, key := range keys {
+			results[i] = goboilerplate.BatchResult{Key: key, Err: goboilerplate.ErrNotFound}
+		}
+		return
+	}
+
+	_ = c.call(ctx, func(ctx context.Context) error {
+		results = goboilerplate.MGet(ctx, c.cacher, keys...)
+		return batchErr(results)
+	})
+	return
+}
+
+func (c *breakerCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) (results []goboilerplate.BatchResult) {
+	if c.isOpen() {
+		results = make([]goboilerplate.BatchResult, len(items))
+		for i, item := range items {
+			results[i] = goboilerplate.BatchResult{Key: item.Key, Value: item.Value}
+		}
+		return
+	}
+
+	_ = c.call(ctx, func(ctx context.Context) error {
+		results = goboilerplate.MSet(ctx, c.cacher, items, expiration)
+		return batchErr(results)
+	})
+	return
+}
+
+func (c *breakerCacher) MDel(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	if c.isOpen() {
+		results = make([]goboilerplate.BatchResult, len(keys))
+		for i, key := range keys {
+			results[i] = goboilerplate.BatchResult{Key: key, Err: ErrBreakerOpen}
+		}
+		return
+	}
+
+	_ = c.callUnbounded(ctx, func(ctx context.Context) error {
+		results = goboilerplate.MDel(ctx, c.cacher, keys...)
+		return batchErr(results)
+	})
+	return
+}
+
+func (c *breakerCacher) FlushWithProgress(
+	ctx context.Context,
+	progress goboilerplate.FlushProgressFunc,
+) (deleted int64, err error) {
+	if c.isOpen() {
+		err = ErrBreakerOpen
+		return
+	}
+
+	err = c.callUnbounded(ctx, func(ctx context.Context) (err error) {
+		deleted, err = goboilerplate.FlushWithProgress(ctx, c.cacher, progress)
+		return
+	})
+	return
+}
+
+func (c *breakerCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) error {
+	if c.isOpen() {
+		return nil
+	}
+
+	return c.call(ctx, func(ctx context.Context) error {
+		return goboilerplate.SetWithTags(ctx, c.cacher, key, value, expiration, tags...)
+	})
+}
+
+func (c *breakerCacher) InvalidateTags(ctx context.Context, tags ...string) error {
+	if c.isOpen() {
+		return ErrBreakerOpen
+	}
+
+	return c.callUnbounded(ctx, func(ctx context.Context) error {
+		return goboilerplate.InvalidateTags(ctx, c.cacher, tags...)
+	})
+}
+
+func (c *breakerCacher) BumpNamespace(ctx context.Context, namespace string) (version int64, err error) {
+	if c.isOpen() {
+		err = ErrBreakerOpen
+		return
+	}
+
+	err = c.callUnbounded(ctx, func(ctx context.Context) (err error) {
+		version, err = goboilerplate.BumpNamespace(ctx, c.cacher, namespace)
+		return
+	})
+	return
+}
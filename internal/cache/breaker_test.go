@@ -0,0 +1,228 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+// flakyCacher fails every call while down.
+type flakyCacher struct {
+	goboilerplate.Cacher
+	down  atomic.Bool
+	calls int32
+}
+
+func (c *flakyCacher) Get(ctx context.Context, key string) (string, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.down.Load() {
+		return "", errors.New("connection refused")
+	}
+	return c.Cacher.Get(ctx, key)
+}
+
+func TestBreakerCacher(t *testing.T) {
+	ctx := context.Background()
+
+	flaky := &flakyCacher{Cacher: memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)}
+	require.NoError(t, flaky.Set(ctx, "key", "value", goboilerplate.DurationShort))
+
+	var healthy atomic.Bool
+	cacher := cache.NewBreakerCacher(flaky, cache.BreakerConf{
+		Name:             "test",
+		FailureThreshold: 2,
+		ProbeInterval:    10 * time.Millisecond,
+	}, func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errors.New("still down")
+		}
+		return nil
+	})
+
+	flaky.down.Store(true)
+	for i := 0; i < 2; i++ {
+		_, err := cacher.Get(ctx, "key")
+		require.EqualError(t, err, "connection refused")
+	}
+
+	// open: short-circuited to a miss without calling the cacher
+	_, err := cacher.Get(ctx, "key")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	require.Equal(t, int32(2), atomic.LoadInt32(&flaky.calls))
+	require.NoError(t, cacher.Set(ctx, "other", "value", goboilerplate.DurationShort))
+	// while invalidations can not be silently dropped
+	require.Equal(t, cache.ErrBreakerOpen, cacher.Del(ctx, "key"))
+
+	flaky.down.Store(false)
+	healthy.Store(true)
+
+	require.Eventually(t, func() bool {
+		res, err := cacher.Get(ctx, "key")
+		return err == nil && res == "value"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBreakerCacher_SlowCalls(t *testing.T) {
+	ctx := context.Background()
+
+	memoryCacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	cacher := cache.NewBreakerCacher(slowCacher{memoryCacher}, cache.BreakerConf{
+		FailureThreshold: 1,
+		SlowThreshold:    time.Millisecond,
+		ProbeInterval:    time.Hour,
+	}, nil)
+
+	require.NoError(t, cacher.Set(ctx, "key", "value", goboilerplate.DurationShort))
+
+	_, err := cacher.Get(ctx, "key")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+}
+
+type slowCacher struct {
+	goboilerplate.Cacher
+}
+
+func (c slowCacher) Set(ctx context.Context, key string, value string, expiration goboilerplate.ExpiryDuration) error {
+	time.Sleep(5 * time.Millisecond)
+	return c.Cacher.Set(ctx, key, value, expiration)
+}
+
+// Flush takes longer than the timeouts of TestBreakerCacher_SlowFlush, unless ctx is done first.
+func (c slowCacher) Flush(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(50 * time.Millisecond):
+	}
+	return c.Cacher.Flush(ctx)
+}
+
+func TestBreakerCacher_SlowFlush(t *testing.T) {
+	ctx := context.Background()
+
+	memoryCacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	require.NoError(t, memoryCacher.Set(ctx, "key", "value", goboilerplate.DurationShort))
+	cacher := cache.NewBreakerCacher(slowCacher{memoryCacher}, cache.BreakerConf{
+		FailureThreshold: 1,
+		SlowThreshold:    time.Millisecond,
+		Timeout:          10 * time.Millisecond,
+		ProbeInterval:    time.Hour,
+	}, nil)
+
+	require.NoError(t, cacher.Flush(ctx))
+	_, err := memoryCacher.Get(ctx, "key")
+	require.Equal(t, goboilerplate.ErrNotFound, errors.Cause(err))
+
+	// still closed
+	require.NoError(t, memoryCacher.Set(ctx, "key", "value", goboilerplate.DurationShort))
+	res, err := cacher.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "value", res)
+}
+
+// wrappingCacher wraps the misses of Get with %w.
+type wrappingCacher struct {
+	goboilerplate.Cacher
+}
+
+func (c wrappingCacher) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.Cacher.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("get %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func TestBreakerCacher_WrappedMiss(t *testing.T) {
+	ctx := context.Background()
+
+	memoryCacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	cacher := cache.NewBreakerCacher(wrappingCacher{memoryCacher}, cache.BreakerConf{
+		FailureThreshold: 1,
+		ProbeInterval:    time.Hour,
+	}, nil)
+
+	_, err := cacher.Get(ctx, "missing")
+	require.ErrorIs(t, err, goboilerplate.ErrNotFound)
+
+	// a miss is no failure, the breaker is still closed
+	require.NoError(t, memoryCacher.Set(ctx, "key", "value", goboilerplate.DurationShort))
+	res, err := cacher.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "value", res)
+}
+
+// hangingCacher hangs until the ctx of the call is done.
+type hangingCacher struct {
+	goboilerplate.Cacher
+	calls int32
+}
+
+func (c *hangingCacher) Get(ctx context.Context, key string) (string, error) {
+	atomic.AddInt32(&c.calls, 1)
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestBreakerCacher_CancelledCaller(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	hanging := &hangingCacher{}
+	cacher := cache.NewBreakerCacher(hanging, cache.BreakerConf{
+		Name:             "test",
+		FailureThreshold: 1,
+		Timeout:          10 * time.Millisecond,
+		ProbeInterval:    time.Hour,
+		MeterProvider:    sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}, nil)
+
+	// the callers giving up tell nothing about the backend
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 2; i++ {
+		_, err := cacher.Get(cancelledCtx, "key")
+		require.Equal(t, context.Canceled, errors.Cause(err))
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&hanging.calls))
+	require.Equal(t, int64(0), breakerState(t, reader))
+
+	// while the breaker's own timeout does
+	_, err := cacher.Get(context.Background(), "key")
+	require.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	_, err = cacher.Get(context.Background(), "key")
+	require.Equal(t, goboilerplate.ErrNotFound, errors.Cause(err))
+	require.Equal(t, int32(3), atomic.LoadInt32(&hanging.calls))
+	require.Equal(t, int64(1), breakerState(t, reader))
+}
+
+// breakerState returns the cache.breaker.state of the breaker named "test".
+func breakerState(t *testing.T, reader sdkmetric.Reader) int64 {
+	t.Helper()
+
+	rm, err := reader.Collect(context.Background())
+	require.NoError(t, err)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "cache.breaker.state" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Gauge[int64]).DataPoints {
+				if name, _ := dp.Attributes.Value("name"); name.AsString() == "test" {
+					return dp.Value
+				}
+			}
+		}
+	}
+	t.Fatal("cache.breaker.state not recorded")
+	return 0
+}
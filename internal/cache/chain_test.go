@@ -0,0 +1,96 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	_redis "github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+// TestCacherChain checks that the cacher built like the application's keeps the optional interfaces of its storage.
+func TestCacherChain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := miniredis.RunT(t)
+	redisClient := _redis.NewClient(&_redis.Options{Addr: server.Addr()})
+	defer redisClient.Close()
+
+	var cacher goboilerplate.Cacher
+	cacher = redis.NewNamespacedRedisCacher(redisClient, goboilerplate.ExpiryConf{}, "chain", time.Minute)
+	cacher, err := cache.NewEncodingCacher(cacher, cache.EncodingConf{
+		Compression: cache.CompressionGzip,
+		Keyring:     cache.Keyring{Current: 1, Keys: map[uint8][]byte{1: make([]byte, 32)}},
+	})
+	require.NoError(t, err)
+	cacher = cache.NewBreakerCacher(cacher, cache.BreakerConf{Name: "chain"}, nil)
+	localCacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	cacher, err = redis.NewTieredCacher(ctx, redisClient, localCacher, cacher, "chain###invalidation")
+	require.NoError(t, err)
+	cacher = cache.NewInstrumentedCacher(cacher, nil, nil)
+	cacher = cache.NewNegativeCacher(cacher)
+
+	require.Implements(t, (*goboilerplate.BatchCacher)(nil), cacher)
+	require.Implements(t, (*goboilerplate.ProgressFlusher)(nil), cacher)
+	require.Implements(t, (*goboilerplate.TagCacher)(nil), cacher)
+	require.Implements(t, (*goboilerplate.NamespaceCacher)(nil), cacher)
+
+	t.Run("batch", func(t *testing.T) {
+		for _, result := range cacher.(goboilerplate.BatchCacher).MSet(ctx, []goboilerplate.KeyValue{
+			{Key: "article:1", Value: "one"},
+			{Key: "article:2", Value: "two"},
+		}, goboilerplate.DurationShort) {
+			require.NoError(t, result.Err)
+		}
+		require.NoError(t, cacher.(goboilerplate.NegativeCacher).SetNotFound(ctx, "article:3"))
+
+		require.Equal(t, []goboilerplate.BatchResult{
+			{Key: "article:1", Value: "one"},
+			{Key: "article:2", Value: "two"},
+			{Key: "article:3", Err: goboilerplate.ErrNegativeCached},
+			{Key: "article:4", Err: goboilerplate.ErrNotFound},
+		}, cacher.(goboilerplate.BatchCacher).MGet(ctx, "article:1", "article:2", "article:3", "article:4"))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		tagCacher := cacher.(goboilerplate.TagCacher)
+		require.NoError(t, tagCacher.SetWithTags(ctx, "article:5", "five", goboilerplate.DurationShort, "author:1"))
+		res, err := cacher.Get(ctx, "article:5")
+		require.NoError(t, err)
+		require.Equal(t, "five", res)
+
+		require.NoError(t, tagCacher.InvalidateTags(ctx, "author:1"))
+
+		_, err = cacher.Get(ctx, "article:5")
+		require.Equal(t, goboilerplate.ErrNotFound, errors.Cause(err))
+	})
+
+	t.Run("namespaces", func(t *testing.T) {
+		require.NoError(t, cacher.Set(ctx, "user:1", "someone", goboilerplate.DurationShort))
+
+		version, err := cacher.(goboilerplate.NamespaceCacher).BumpNamespace(ctx, "user")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), version)
+
+		_, err = cacher.Get(ctx, "user:1")
+		require.Equal(t, goboilerplate.ErrNotFound, errors.Cause(err))
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		deleted, err := cacher.(goboilerplate.ProgressFlusher).FlushWithProgress(ctx, nil)
+		require.NoError(t, err)
+		require.Positive(t, deleted)
+
+		_, err = cacher.Get(ctx, "article:1")
+		require.Equal(t, goboilerplate.ErrNotFound, errors.Cause(err))
+	})
+}
@@ -0,0 +1,337 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// encodedHeaderPrefix marks an encoded value, as in "\x00enc<compression><key version><payload>".
+const encodedHeaderPrefix = "\x00enc"
+
+// Compression is the compression algorithm of the cached values.
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionSnappy
+)
+
+// ParseCompression parses the name of a compression algorithm: "none", "gzip" or "snappy".
+func ParseCompression(name string) (Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	}
+	return CompressionNone, errors.Errorf("unknown compression '%s'", name)
+}
+
+// Keyring holds the AES keys by version. Values are encrypted with the current key, and decrypted with the key of
+// the version they were encrypted with, so that keys can be rotated by adding a new version and making it current.
+// Version 0 means no encryption.
+type Keyring struct {
+	Current uint8
+	Keys    map[uint8][]byte
+}
+
+// EncodingConf is the configuration of an encoding cacher.
+type EncodingConf struct {
+	Compression Compression
+	// CompressionThreshold is the size in bytes from which values are compressed.
+	CompressionThreshold int
+	// Keyring enables encryption when its current version is not 0.
+	Keyring Keyring
+}
+
+type encodingCacher struct {
+	cacher goboilerplate.Cacher
+	conf   EncodingConf
+	aeads  map[uint8]cipher.AEAD
+}
+
+// NewEncodingCacher is a constructor for a cacher compressing and encrypting the values stored in cacher. Values
+// under the compression threshold are stored uncompressed, and values stored without the header (e.g. before the
+// encoding was enabled) are returned as is. Values that can not be decoded are treated as a miss.
+//
+// Encryption uses AES-GCM with the key as additional data, so that an entry can not be copied over another key.
+//
+// The returned cacher implements goboilerplate.BatchCacher, goboilerplate.ProgressFlusher, goboilerplate.TagCacher
+// and goboilerplate.NamespaceCacher on top of cacher, encoding the values going through them.
+func NewEncodingCacher(cacher goboilerplate.Cacher, conf EncodingConf) (goboilerplate.Cacher, error) {
+	aeads := make(map[uint8]cipher.AEAD)
+	for version, key := range conf.Keyring.Keys {
+		if version == 0 {
+			return nil, errors.New("key version 0 is reserved for unencrypted values")
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key version %d", version)
+		}
+		aeads[version], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error initializing AES-GCM with key version %d", version)
+		}
+	}
+
+	if conf.Keyring.Current != 0 && aeads[conf.Keyring.Current] == nil {
+		return nil, errors.Errorf("current key version %d is not in the keyring", conf.Keyring.Current)
+	}
+
+	return encodingCacher{
+		cacher: cacher,
+		conf:   conf,
+		aeads:  aeads,
+	}, nil
+}
+
+func (c encodingCacher) encode(key string, value string) (encoded string, err error) {
+	compression := CompressionNone
+	if c.conf.Compression != CompressionNone && len(value) >= c.conf.CompressionThreshold {
+		compression = c.conf.Compression
+	}
+	if compression == CompressionNone && c.conf.Keyring.Current == 0 {
+		encoded = value
+		return
+	}
+
+	payload := []byte(value)
+	switch compression {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err = w.Write(payload); err != nil {
+			err = errors.Wrap(err, "error compressing with gzip")
+			return
+		}
+		if err = w.Close(); err != nil {
+			err = errors.Wrap(err, "error compressing with gzip")
+			return
+		}
+		payload = buf.Bytes()
+	case CompressionSnappy:
+		payload = snappy.Encode(nil, payload)
+	}
+
+	header := []byte(encodedHeaderPrefix + string([]byte{byte(compression), c.conf.Keyring.Current}))
+	if c.conf.Keyring.Current != 0 {
+		aead := c.aeads[c.conf.Keyring.Current]
+		nonce := make([]byte, aead.NonceSize())
+		if _, err = rand.Read(nonce); err != nil {
+			err = errors.Wrap(err, "error generating nonce")
+			return
+		}
+		payload = aead.Seal(nonce, nonce, payload, additionalData(header, key))
+	}
+
+	encoded = string(header) + string(payload)
+	return
+}
+
+func (c encodingCacher) decode(key string, encoded string) (value string, err error) {
+	if !strings.HasPrefix(encoded, encodedHeaderPrefix) {
+		value = encoded
+		return
+	}
+	if len(encoded) < len(encodedHeaderPrefix)+2 {
+		err = errors.New("truncated header")
+		return
+	}
+
+	header := []byte(encoded[:len(encodedHeaderPrefix)+2])
+	compression := Compression(header[len(header)-2])
+	version := header[len(header)-1]
+	payload := []byte(encoded[len(header):])
+
+	if version != 0 {
+		aead, ok := c.aeads[version]
+		if !ok {
+			err = errors.Errorf("unknown key version %d", version)
+			return
+		}
+		if len(payload) < aead.NonceSize() {
+			err = errors.New("truncated ciphertext")
+			return
+		}
+
+		nonce, ciphertext := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+		payload, err = aead.Open(nil, nonce, ciphertext, additionalData(header, key))
+		if err != nil {
+			err = errors.Wrapf(err, "error decrypting with key version %d", version)
+			return
+		}
+	}
+
+	switch compression {
+	case CompressionNone:
+	case CompressionGzip:
+		var r *gzip.Reader
+		r, err = gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			err = errors.Wrap(err, "error decompressing with gzip")
+			return
+		}
+		defer r.Close()
+
+		payload, err = io.ReadAll(r)
+		if err != nil {
+			err = errors.Wrap(err, "error decompressing with gzip")
+			return
+		}
+	case CompressionSnappy:
+		payload, err = snappy.Decode(nil, payload)
+		if err != nil {
+			err = errors.Wrap(err, "error decompressing with snappy")
+			return
+		}
+	default:
+		err = errors.Errorf("unknown compression %d", compression)
+		return
+	}
+
+	value = string(payload)
+	return
+}
+
+func additionalData(header []byte, key string) []byte {
+	return append(append([]byte{}, header...), key...)
+}
+
+func (c encodingCacher) Get(ctx context.Context, key string) (value string, err error) {
+	var encoded string
+	encoded, err = c.cacher.Get(ctx, key)
+	if err != nil {
+		return
+	}
+
+	value, err = c.decode(key, encoded)
+	if err != nil {
+		logrus.Warnf("Error decoding cached '%s', treating it as a miss: %+v", key, err)
+		err = goboilerplate.ErrNotFound
+	}
+	return
+}
+
+func (c encodingCacher) Set(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+) (err error) {
+	var encoded string
+	encoded, err = c.encode(key, value)
+	if err != nil {
+		err = errors.Wrapf(err, "error encoding '%s'", key)
+		return
+	}
+
+	err = c.cacher.Set(ctx, key, encoded, expiration)
+	return
+}
+
+func (c encodingCacher) Del(ctx context.Context, key string) error {
+	return c.cacher.Del(ctx, key)
+}
+
+func (c encodingCacher) Flush(ctx context.Context) error {
+	return c.cacher.Flush(ctx)
+}
+
+func (c encodingCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = goboilerplate.MGet(ctx, c.cacher, keys...)
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		results[i].Value, results[i].Err = c.decode(result.Key, result.Value)
+		if results[i].Err != nil {
+			logrus.Warnf("Error decoding cached '%s', treating it as a miss: %+v", result.Key, results[i].Err)
+			results[i].Err = goboilerplate.ErrNotFound
+		}
+	}
+	return
+}
+
+// MSet sets the items that could be encoded, the others failing with their encoding error.
+func (c encodingCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(items))
+	encoded := make([]goboilerplate.KeyValue, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i] = goboilerplate.BatchResult{Key: item.Key, Value: item.Value}
+
+		value, err := c.encode(item.Key, item.Value)
+		if err != nil {
+			results[i].Err = errors.Wrapf(err, "error encoding '%s'", item.Key)
+			continue
+		}
+		encoded = append(encoded, goboilerplate.KeyValue{Key: item.Key, Value: value})
+		indexes = append(indexes, i)
+	}
+	if len(encoded) == 0 {
+		return
+	}
+
+	for i, result := range goboilerplate.MSet(ctx, c.cacher, encoded, expiration) {
+		results[indexes[i]].Err = result.Err
+	}
+	return
+}
+
+func (c encodingCacher) MDel(ctx context.Context, keys ...string) []goboilerplate.BatchResult {
+	return goboilerplate.MDel(ctx, c.cacher, keys...)
+}
+
+func (c encodingCacher) FlushWithProgress(
+	ctx context.Context,
+	progress goboilerplate.FlushProgressFunc,
+) (deleted int64, err error) {
+	return goboilerplate.FlushWithProgress(ctx, c.cacher, progress)
+}
+
+func (c encodingCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) (err error) {
+	var encoded string
+	encoded, err = c.encode(key, value)
+	if err != nil {
+		err = errors.Wrapf(err, "error encoding '%s'", key)
+		return
+	}
+
+	err = goboilerplate.SetWithTags(ctx, c.cacher, key, encoded, expiration, tags...)
+	return
+}
+
+func (c encodingCacher) InvalidateTags(ctx context.Context, tags ...string) error {
+	return goboilerplate.InvalidateTags(ctx, c.cacher, tags...)
+}
+
+func (c encodingCacher) BumpNamespace(ctx context.Context, namespace string) (version int64, err error) {
+	return goboilerplate.BumpNamespace(ctx, c.cacher, namespace)
+}
@@ -0,0 +1,123 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+func TestEncodingCacher(t *testing.T) {
+	ctx := context.Background()
+
+	key1 := bytes.Repeat([]byte{1}, 32)
+	key2 := bytes.Repeat([]byte{2}, 32)
+	large := strings.Repeat("a large article payload ", 100)
+
+	keyring := cache.Keyring{Current: 1, Keys: map[uint8][]byte{1: key1}}
+
+	tests := map[string]cache.EncodingConf{
+		"gzip":              {Compression: cache.CompressionGzip, CompressionThreshold: 100},
+		"snappy":            {Compression: cache.CompressionSnappy, CompressionThreshold: 100},
+		"encryption":        {Keyring: keyring},
+		"snappy encryption": {Compression: cache.CompressionSnappy, CompressionThreshold: 100, Keyring: keyring},
+	}
+
+	for testName, conf := range tests {
+		t.Run(testName, func(t *testing.T) {
+			backend := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+			cacher, err := cache.NewEncodingCacher(backend, conf)
+			require.NoError(t, err)
+
+			for _, value := range []string{"small", large} {
+				require.NoError(t, cacher.Set(ctx, "key", value, goboilerplate.DurationShort))
+
+				res, err := cacher.Get(ctx, "key")
+				require.NoError(t, err)
+				require.Equal(t, value, res)
+
+				stored, err := backend.Get(ctx, "key")
+				require.NoError(t, err)
+				if conf.Keyring.Current != 0 {
+					require.NotContains(t, stored, value[:5])
+				}
+				if conf.Compression != cache.CompressionNone && value == large {
+					require.Less(t, len(stored), len(large))
+				}
+			}
+		})
+	}
+
+	t.Run("key rotation", func(t *testing.T) {
+		backend := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		oldCacher, err := cache.NewEncodingCacher(backend, cache.EncodingConf{
+			Keyring: cache.Keyring{Current: 1, Keys: map[uint8][]byte{1: key1}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, oldCacher.Set(ctx, "key", "secret", goboilerplate.DurationShort))
+
+		newCacher, err := cache.NewEncodingCacher(backend, cache.EncodingConf{
+			Keyring: cache.Keyring{Current: 2, Keys: map[uint8][]byte{1: key1, 2: key2}},
+		})
+		require.NoError(t, err)
+
+		res, err := newCacher.Get(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, "secret", res)
+
+		// once the old key is dropped, its entries are misses
+		droppedCacher, err := cache.NewEncodingCacher(backend, cache.EncodingConf{
+			Keyring: cache.Keyring{Current: 2, Keys: map[uint8][]byte{2: key2}},
+		})
+		require.NoError(t, err)
+
+		_, err = droppedCacher.Get(ctx, "key")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	})
+
+	t.Run("entry copied to another key", func(t *testing.T) {
+		backend := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		cacher, err := cache.NewEncodingCacher(backend, cache.EncodingConf{
+			Keyring: cache.Keyring{Current: 1, Keys: map[uint8][]byte{1: key1}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, cacher.Set(ctx, "user:1", "secret", goboilerplate.DurationShort))
+
+		stored, err := backend.Get(ctx, "user:1")
+		require.NoError(t, err)
+		require.NoError(t, backend.Set(ctx, "user:2", stored, goboilerplate.DurationShort))
+
+		_, err = cacher.Get(ctx, "user:2")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	})
+
+	t.Run("plain value", func(t *testing.T) {
+		backend := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		cacher, err := cache.NewEncodingCacher(backend, cache.EncodingConf{Compression: cache.CompressionGzip})
+		require.NoError(t, err)
+		require.NoError(t, backend.Set(ctx, "key", "stored before", goboilerplate.DurationShort))
+
+		res, err := cacher.Get(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, "stored before", res)
+	})
+
+	t.Run("invalid keyring", func(t *testing.T) {
+		_, err := cache.NewEncodingCacher(nil, cache.EncodingConf{
+			Keyring: cache.Keyring{Current: 2, Keys: map[uint8][]byte{1: key1}},
+		})
+		require.Error(t, err)
+
+		_, err = cache.NewEncodingCacher(nil, cache.EncodingConf{
+			Keyring: cache.Keyring{Current: 1, Keys: map[uint8][]byte{1: []byte("short")}},
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entryHeaderPrefix marks a value stored with its soft expiry metadata, as in "\x00swr:<soft expiry>:<delta>:<value>".
+const entryHeaderPrefix = "\x00swr:"
+
+// entry is a cached value along with the metadata needed for stale-while-revalidate.
+type entry struct {
+	value string
+	// softExpiry is the time after which the value is stale and should be refreshed.
+	softExpiry time.Time
+	// delta is how long it took to load the value.
+	delta time.Duration
+}
+
+func (e entry) encode() string {
+	if e.softExpiry.IsZero() {
+		return e.value
+	}
+	return fmt.Sprintf("%s%d:%d:%s", entryHeaderPrefix, e.softExpiry.UnixMilli(), e.delta.Milliseconds(), e.value)
+}
+
+// decodeEntry decodes the raw cached value. Values without the header are returned as is, without a soft expiry.
+func decodeEntry(raw string) entry {
+	if !strings.HasPrefix(raw, entryHeaderPrefix) {
+		return entry{value: raw}
+	}
+
+	parts := strings.SplitN(raw[len(entryHeaderPrefix):], ":", 3)
+	if len(parts) != 3 {
+		return entry{value: raw}
+	}
+
+	softExpiry, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return entry{value: raw}
+	}
+	delta, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return entry{value: raw}
+	}
+
+	return entry{
+		value:      parts[2],
+		softExpiry: time.UnixMilli(softExpiry),
+		delta:      time.Duration(delta) * time.Millisecond,
+	}
+}
+
+// shouldRefresh tells whether the entry is stale, or probabilistically about to be, using XFetch: the closer to the
+// soft expiry and the longer the value takes to load, the more likely it is refreshed early.
+// See: https://cseweb.ucsd.edu/~avattani/papers/cache_stampede.pdf
+func (e entry) shouldRefresh(now time.Time, beta float64) bool {
+	if e.softExpiry.IsZero() {
+		return false
+	}
+
+	early := time.Duration(float64(e.delta) * beta * -math.Log(1-rand.Float64()))
+	return !now.Add(early).Before(e.softExpiry)
+}
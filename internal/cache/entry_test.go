@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntry(t *testing.T) {
+	t.Run("encode and decode", func(t *testing.T) {
+		e := entry{
+			value:      "some:value",
+			softExpiry: time.UnixMilli(time.Now().UnixMilli()),
+			delta:      150 * time.Millisecond,
+		}
+		require.Equal(t, e, decodeEntry(e.encode()))
+	})
+
+	t.Run("value without header", func(t *testing.T) {
+		require.Equal(t, entry{value: "some-value"}, decodeEntry("some-value"))
+	})
+}
+
+func TestEntry_ShouldRefresh(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		entry    entry
+		expected bool
+	}{
+		"without soft expiry": {
+			entry:    entry{value: "v"},
+			expected: false,
+		},
+		"stale": {
+			entry:    entry{value: "v", softExpiry: now.Add(-time.Second)},
+			expected: true,
+		},
+		"fresh and fast to load": {
+			entry:    entry{value: "v", softExpiry: now.Add(time.Hour), delta: time.Millisecond},
+			expected: false,
+		},
+		"about to be stale and slow to load": {
+			entry:    entry{value: "v", softExpiry: now.Add(time.Millisecond), delta: time.Hour},
+			expected: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			require.Equal(t, test.expected, test.entry.shouldRefresh(now, 1))
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.opentelemetry.io/otel/trace"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+const (
+	resultHit   = "hit"
+	resultMiss  = "miss"
+	resultOK    = "ok"
+	resultError = "error"
+
+	// defaultNamespace is the namespace of the keys without one.
+	defaultNamespace = "default"
+)
+
+type instrumentedCacher struct {
+	cacher goboilerplate.Cacher
+	tracer trace.Tracer
+
+	requests  syncint64.Counter
+	duration  syncfloat64.Histogram
+	valueSize syncint64.Histogram
+}
+
+// NewInstrumentedCacher is a constructor for a cacher recording a span and metrics for every operation of cacher:
+//   - cache.requests, counted by op and result (hit, miss, ok or error), from which the hit ratio is derived
+//   - cache.duration, the latency of the operations in milliseconds
+//   - cache.value_size, the size of the values got and set in bytes
+//
+// All of them are labelled with the key namespace, the part of the key before the first ':' ("article" for
+// "article:42"), and Set with the expiry tier. The global providers are used when meterProvider or
+// tracerProvider is nil.
+//
+// The returned cacher implements goboilerplate.BatchCacher, goboilerplate.ProgressFlusher, goboilerplate.TagCacher
+// and goboilerplate.NamespaceCacher on top of cacher, see the helpers of the same name for the fallbacks.
+func NewInstrumentedCacher(
+	cacher goboilerplate.Cacher,
+	meterProvider metric.MeterProvider,
+	tracerProvider trace.TracerProvider,
+) goboilerplate.Cacher {
+	if meterProvider == nil {
+		meterProvider = global.MeterProvider()
+	}
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	meter := meterProvider.Meter(instrumentationName)
+
+	requests, err := meter.SyncInt64().Counter(
+		"cache.requests",
+		instrument.WithDescription("The number of cache operations, by op and result."),
+	)
+	if err != nil {
+		logrus.Errorf("Error creating cache.requests counter: %+v", err)
+	}
+
+	duration, err := meter.SyncFloat64().Histogram(
+		"cache.duration",
+		instrument.WithDescription("The duration of cache operations."),
+		instrument.WithUnit(unit.Milliseconds),
+	)
+	if err != nil {
+		logrus.Errorf("Error creating cache.duration histogram: %+v", err)
+	}
+
+	valueSize, err := meter.SyncInt64().Histogram(
+		"cache.value_size",
+		instrument.WithDescription("The size of the values got from and set to the cache."),
+		instrument.WithUnit(unit.Bytes),
+	)
+	if err != nil {
+		logrus.Errorf("Error creating cache.value_size histogram: %+v", err)
+	}
+
+	return instrumentedCacher{
+		cacher:    cacher,
+		tracer:    tracerProvider.Tracer(instrumentationName),
+		requests:  requests,
+		duration:  duration,
+		valueSize: valueSize,
+	}
+}
+
+// keyNamespace returns the part of key before the first ':', keeping the metric cardinality bounded.
+func keyNamespace(key string) string {
+	namespace, ok := goboilerplate.KeyNamespace(key)
+	if !ok {
+		return defaultNamespace
+	}
+	return namespace
+}
+
+// start starts the span of op. The returned function ends it and records the metrics given the operation result.
+func (c instrumentedCacher) start(
+	ctx context.Context,
+	op string,
+	attrs ...attribute.KeyValue,
+) (context.Context, func(result string, err error)) {
+	start := time.Now()
+	ctx, span := c.tracer.Start(ctx, "cache."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, func(result string, err error) {
+		span.SetAttributes(attribute.String("cache.result", result))
+		if result == resultError {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		attrs := append([]attribute.KeyValue{attribute.String("op", op), attribute.String("result", result)}, attrs...)
+		if c.requests != nil {
+			c.requests.Add(ctx, 1, attrs...)
+		}
+		if c.duration != nil {
+			c.duration.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), attrs...)
+		}
+	}
+}
+
+func (c instrumentedCacher) recordValueSize(ctx context.Context, op string, value string, attrs ...attribute.KeyValue) {
+	if c.valueSize == nil {
+		return
+	}
+	c.valueSize.Record(ctx, int64(len(value)), append([]attribute.KeyValue{attribute.String("op", op)}, attrs...)...)
+}
+
+func (c instrumentedCacher) Get(ctx context.Context, key string) (value string, err error) {
+	attrs := []attribute.KeyValue{attribute.String("namespace", keyNamespace(key))}
+	ctx, end := c.start(ctx, "get", attrs...)
+
+	value, err = c.cacher.Get(ctx, key)
+	switch {
+	case err == nil:
+		end(resultHit, nil)
+		c.recordValueSize(ctx, "get", value, attrs...)
+	case errors.Is(err, goboilerplate.ErrNotFound):
+		end(resultMiss, nil)
+	default:
+		end(resultError, err)
+	}
+	return
+}
+
+func (c instrumentedCacher) Set(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+) (err error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("namespace", keyNamespace(key)),
+		attribute.String("expiry", string(expiration)),
+	}
+	ctx, end := c.start(ctx, "set", attrs...)
+
+	err = c.cacher.Set(ctx, key, value, expiration)
+	if err != nil {
+		end(resultError, err)
+		return
+	}
+
+	end(resultOK, nil)
+	c.recordValueSize(ctx, "set", value, attrs...)
+	return
+}
+
+func (c instrumentedCacher) Del(ctx context.Context, key string) (err error) {
+	ctx, end := c.start(ctx, "del", attribute.String("namespace", keyNamespace(key)))
+
+	err = c.cacher.Del(ctx, key)
+	switch {
+	case err == nil:
+		end(resultOK, nil)
+	case errors.Is(err, goboilerplate.ErrNotFound):
+		end(resultMiss, nil)
+	default:
+		end(resultError, err)
+	}
+	return
+}
+
+func (c instrumentedCacher) Flush(ctx context.Context) (err error) {
+	ctx, end := c.start(ctx, "flush")
+
+	err = c.cacher.Flush(ctx)
+	if err != nil {
+		end(resultError, err)
+		return
+	}
+
+	end(resultOK, nil)
+	return
+}
+
+// endBatch ends the span of a batch operation, failed if any key failed for another reason than a miss.
+func (c instrumentedCacher) endBatch(end func(result string, err error), results []goboilerplate.BatchResult) {
+	if err := batchErr(results); err != nil {
+		end(resultError, err)
+		return
+	}
+	end(resultOK, nil)
+}
+
+func (c instrumentedCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	ctx, end := c.start(ctx, "mget")
+
+	results = goboilerplate.MGet(ctx, c.cacher, keys...)
+	c.endBatch(end, results)
+	for _, result := range results {
+		if result.Err == nil {
+			c.recordValueSize(ctx, "mget", result.Value, attribute.String("namespace", keyNamespace(result.Key)))
+		}
+	}
+	return
+}
+
+func (c instrumentedCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) (results []goboilerplate.BatchResult) {
+	ctx, end := c.start(ctx, "mset", attribute.String("expiry", string(expiration)))
+
+	results = goboilerplate.MSet(ctx, c.cacher, items, expiration)
+	c.endBatch(end, results)
+	return
+}
+
+func (c instrumentedCacher) MDel(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	ctx, end := c.start(ctx, "mdel")
+
+	results = goboilerplate.MDel(ctx, c.cacher, keys...)
+	c.endBatch(end, results)
+	return
+}
+
+func (c instrumentedCacher) FlushWithProgress(
+	ctx context.Context,
+	progress goboilerplate.FlushProgressFunc,
+) (deleted int64, err error) {
+	ctx, end := c.start(ctx, "flush")
+
+	deleted, err = goboilerplate.FlushWithProgress(ctx, c.cacher, progress)
+	if err != nil {
+		end(resultError, err)
+		return
+	}
+
+	end(resultOK, nil)
+	return
+}
+
+func (c instrumentedCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) (err error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("namespace", keyNamespace(key)),
+		attribute.String("expiry", string(expiration)),
+	}
+	ctx, end := c.start(ctx, "set_with_tags", attrs...)
+
+	err = goboilerplate.SetWithTags(ctx, c.cacher, key, value, expiration, tags...)
+	if err != nil {
+		end(resultError, err)
+		return
+	}
+
+	end(resultOK, nil)
+	c.recordValueSize(ctx, "set_with_tags", value, attrs...)
+	return
+}
+
+func (c instrumentedCacher) InvalidateTags(ctx context.Context, tags ...string) (err error) {
+	ctx, end := c.start(ctx, "invalidate_tags")
+
+	err = goboilerplate.InvalidateTags(ctx, c.cacher, tags...)
+	if err != nil {
+		end(resultError, err)
+		return
+	}
+
+	end(resultOK, nil)
+	return
+}
+
+func (c instrumentedCacher) BumpNamespace(ctx context.Context, namespace string) (version int64, err error) {
+	ctx, end := c.start(ctx, "bump_namespace", attribute.String("namespace", namespace))
+
+	version, err = goboilerplate.BumpNamespace(ctx, c.cacher, namespace)
+	if err != nil {
+		end(resultError, err)
+		return
+	}
+
+	end(resultOK, nil)
+	return
+}
@@ -0,0 +1,68 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+func TestInstrumentedCacher(t *testing.T) {
+	ctx := context.Background()
+
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+	memoryCacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	cacher := cache.NewInstrumentedCacher(memoryCacher, meterProvider, tracerProvider)
+
+	require.NoError(t, cacher.Set(ctx, "article:1", "value", goboilerplate.DurationLong))
+	_, err := cacher.Get(ctx, "article:1")
+	require.NoError(t, err)
+	_, err = cacher.Get(ctx, "article:2")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "no-namespace")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+
+	rm, err := reader.Collect(ctx)
+	require.NoError(t, err)
+
+	requests := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "cache.requests" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				op, _ := dp.Attributes.Value("op")
+				result, _ := dp.Attributes.Value("result")
+				namespace, _ := dp.Attributes.Value("namespace")
+				requests[op.AsString()+"/"+result.AsString()+"/"+namespace.AsString()] += dp.Value
+			}
+		}
+	}
+	require.Equal(t, map[string]int64{
+		"set/ok/article":   1,
+		"get/hit/article":  1,
+		"get/miss/article": 1,
+		"get/miss/default": 1,
+	}, requests)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 4)
+	require.Equal(t, "cache.set", ended[0].Name())
+	require.Contains(t, ended[0].Attributes(), attribute.String("expiry", string(goboilerplate.DurationLong)))
+	require.Contains(t, ended[1].Attributes(), attribute.String("cache.result", "hit"))
+}
@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+const (
+	defaultLockTTL      = 5 * time.Second
+	defaultPollInterval = 50 * time.Millisecond
+)
+
+// LoadFunc loads the value of a cache entry from the source of truth.
+type LoadFunc func(ctx context.Context) (value string, err error)
+
+// Loader implements the cache-aside pattern on top of a Cacher, collapsing concurrent misses of the same key into
+// a single load.
+type Loader struct {
+	cacher goboilerplate.Cacher
+	group  singleflight.Group
+
+	locker       goboilerplate.Locker
+	lockTTL      time.Duration
+	pollInterval time.Duration
+
+	softExpiryConf goboilerplate.ExpiryConf
+	beta           float64
+	refreshing     sync.Map
+}
+
+// NewLoader is a constructor for Loader.
+// Concurrent misses are always coalesced within the process. When locker is not nil, they are also coalesced across
+// replicas: the replica holding the lock loads the value while the others wait up to lockTTL for it to be cached.
+func NewLoader(cacher goboilerplate.Cacher, locker goboilerplate.Locker, lockTTL time.Duration) *Loader {
+	if lockTTL <= 0 {
+		lockTTL = defaultLockTTL
+	}
+
+	return &Loader{
+		cacher:       cacher,
+		locker:       locker,
+		lockTTL:      lockTTL,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// NewRefreshingLoader is a constructor for Loader in stale-while-revalidate mode.
+// Each entry is stored along with its soft expiry, taken from softExpiryConf for the entry's expiry duration. Past
+// its soft expiry, an entry is still served while a single caller refreshes it in the background. Refreshes may also
+// start early, with a probability growing as the soft expiry gets closer (XFetch); a beta greater than 1 favours
+// earlier refreshes. Expiry durations missing from softExpiryConf are never refreshed.
+//
+// Entries written in this mode carry a header, so they should only be accessed through the Loader.
+func NewRefreshingLoader(
+	cacher goboilerplate.Cacher,
+	locker goboilerplate.Locker,
+	lockTTL time.Duration,
+	softExpiryConf goboilerplate.ExpiryConf,
+	beta float64,
+) *Loader {
+	l := NewLoader(cacher, locker, lockTTL)
+	l.softExpiryConf = softExpiryConf
+	l.beta = beta
+	return l
+}
+
+// Get gets the value of key from the cache.
+func (l *Loader) Get(ctx context.Context, key string) (value string, err error) {
+	var raw string
+	raw, err = l.cacher.Get(ctx, key)
+	if err != nil {
+		return
+	}
+
+	value = decodeEntry(raw).value
+	return
+}
+
+// Set sets the value of key to the cache.
+func (l *Loader) Set(ctx context.Context, key string, value string, expiration goboilerplate.ExpiryDuration) error {
+	return l.set(ctx, key, value, expiration, 0)
+}
+
+func (l *Loader) set(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	delta time.Duration,
+) error {
+	e := entry{
+		value: value,
+		delta: delta,
+	}
+	if softExpiry, ok := l.softExpiryConf[expiration]; ok && softExpiry.TTL > 0 {
+		e.softExpiry = time.Now().Add(l.softExpiryConf.TTL(expiration))
+	}
+
+	return l.cacher.Set(ctx, key, e.encode(), expiration)
+}
+
+// GetOrLoad gets the value of key from the cache. On a miss, the value is loaded with loader and then cached with
+// the given expiration. Errors from the cache are treated as a miss, while errors from the loader are returned as is.
+// The load is shared by the concurrent callers, so it runs detached from their cancellation, each caller only waiting
+// for it until its own ctx is done.
+// If the cacher is a goboilerplate.NegativeCacher, ErrNotFound from the loader is cached as well, and later calls
+// return ErrNegativeCached without calling the loader.
+func (l *Loader) GetOrLoad(
+	ctx context.Context,
+	key string,
+	expiration goboilerplate.ExpiryDuration,
+	loader LoadFunc,
+) (value string, err error) {
+	var raw string
+	raw, err = l.cacher.Get(ctx, key)
+	if err == nil {
+		e := decodeEntry(raw)
+		if e.shouldRefresh(time.Now(), l.beta) {
+			l.refresh(key, expiration, loader)
+		}
+		value = e.value
+		return
+	}
+	if errors.Is(err, goboilerplate.ErrNegativeCached) {
+		return
+	}
+	if !errors.Is(err, goboilerplate.ErrNotFound) {
+		logrus.Warnf("Error getting '%s' from cache: %+v", key, err)
+	}
+
+	// the load is shared by every caller of key, so it must not be cancelled along with the first one
+	ch := l.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, l.loadTimeout())
+		defer cancel()
+
+		return l.load(loadCtx, key, expiration, loader)
+	})
+
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	case res := <-ch:
+		if res.Err != nil {
+			err = res.Err
+			return
+		}
+		value, err = res.Val.(string), nil
+		return
+	}
+}
+
+// detachedContext carries the values of its parent, e.g. the span, but neither its deadline nor its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// loadTimeout bounds a shared load: waiting up to lockTTL for another replica, then loading for up to lockTTL.
+func (l *Loader) loadTimeout() time.Duration {
+	return 2 * l.lockTTL
+}
+
+// loadLockKey returns the key of the lock guarding the load of key, apart from the other locks.
+func loadLockKey(key string) string {
+	return "load###" + key
+}
+
+func (l *Loader) load(
+	ctx context.Context,
+	key string,
+	expiration goboilerplate.ExpiryDuration,
+	loader LoadFunc,
+) (value string, err error) {
+	if l.locker != nil {
+		lock, err := l.locker.TryAcquire(ctx, loadLockKey(key), l.lockTTL)
+		switch {
+		case err == goboilerplate.ErrLockNotAcquired:
+			if value, err := l.waitForValue(ctx, key); err == nil {
+				return value, nil
+			}
+		case err != nil:
+			logrus.Warnf("Error locking '%s', loading without lock: %+v", key, err)
+		default:
+			defer func() {
+				if err := lock.Release(ctx); err != nil {
+					logrus.Warnf("Error unlocking '%s': %+v", key, err)
+				}
+			}()
+		}
+	}
+
+	return l.loadAndSet(ctx, key, expiration, loader)
+}
+
+func (l *Loader) loadAndSet(
+	ctx context.Context,
+	key string,
+	expiration goboilerplate.ExpiryDuration,
+	loader LoadFunc,
+) (value string, err error) {
+	start := time.Now()
+	value, err = loader(ctx)
+	if errors.Is(err, goboilerplate.ErrNotFound) {
+		if nc, ok := l.cacher.(goboilerplate.NegativeCacher); ok {
+			if err := nc.SetNotFound(ctx, key); err != nil {
+				logrus.Warnf("Error setting '%s' as not found to cache: %+v", key, err)
+			}
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if err := l.set(ctx, key, value, expiration, time.Since(start)); err != nil {
+		logrus.Warnf("Error setting '%s' to cache: %+v", key, err)
+	}
+	return
+}
+
+// refresh reloads the value in the background, unless it's already being refreshed by this process or, when there is
+// a locker, by another replica.
+func (l *Loader) refresh(key string, expiration goboilerplate.ExpiryDuration, loader LoadFunc) {
+	if _, refreshing := l.refreshing.LoadOrStore(key, struct{}{}); refreshing {
+		return
+	}
+
+	go func() {
+		defer l.refreshing.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), l.lockTTL)
+		defer cancel()
+
+		if l.locker != nil {
+			lock, err := l.locker.TryAcquire(ctx, loadLockKey(key), l.lockTTL)
+			if err == goboilerplate.ErrLockNotAcquired {
+				return
+			}
+			if err != nil {
+				logrus.Warnf("Error locking '%s' for refresh: %+v", key, err)
+				return
+			}
+			defer func() {
+				if err := lock.Release(ctx); err != nil {
+					logrus.Warnf("Error unlocking '%s': %+v", key, err)
+				}
+			}()
+		}
+
+		if _, err := l.loadAndSet(ctx, key, expiration, loader); err != nil {
+			logrus.Warnf("Error refreshing '%s': %+v", key, err)
+		}
+	}()
+}
+
+// waitForValue polls the cache until another replica has loaded the value or the lock has expired.
+func (l *Loader) waitForValue(ctx context.Context, key string) (value string, err error) {
+	ticker := time.NewTicker(l.pollInterval)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(l.lockTTL)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-deadline.C:
+			err = goboilerplate.ErrNotFound
+			return
+		case <-ticker.C:
+			value, err = l.Get(ctx, key)
+			if err == nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+// heldLocker simulates a lock already held by another replica.
+type heldLocker struct{}
+
+func (heldLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (goboilerplate.Lock, error) {
+	return nil, goboilerplate.ErrLockNotAcquired
+}
+
+func (heldLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (goboilerplate.Lock, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hit", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		require.NoError(t, cacher.Set(ctx, "key", "cached", goboilerplate.DurationShort))
+
+		loader := cache.NewLoader(cacher, nil, 0)
+		res, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+			t.Fatal("loader should not be called")
+			return "", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "cached", res)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+
+		loader := cache.NewLoader(cacher, nil, 0)
+		res, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+			return "loaded", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "loaded", res)
+
+		res, err = cacher.Get(ctx, "key")
+		require.NoError(t, err)
+		require.Equal(t, "loaded", res)
+	})
+
+	t.Run("loader error", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+
+		loader := cache.NewLoader(cacher, nil, 0)
+		_, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+			return "", errors.New("storage error")
+		})
+		require.EqualError(t, err, "storage error")
+
+		_, err = cacher.Get(ctx, "key")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	})
+
+	t.Run("concurrent misses are coalesced", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		loader := cache.NewLoader(cacher, nil, 0)
+
+		var calls int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+					atomic.AddInt32(&calls, 1)
+					time.Sleep(50 * time.Millisecond)
+					return "loaded", nil
+				})
+				require.NoError(t, err)
+				require.Equal(t, "loaded", res)
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("waits for another replica", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		loader := cache.NewLoader(cacher, heldLocker{}, time.Second)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_ = cacher.Set(ctx, "key", "from-other-replica", goboilerplate.DurationShort)
+		}()
+
+		res, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+			t.Fatal("loader should not be called")
+			return "", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "from-other-replica", res)
+	})
+
+	t.Run("loads when the other replica does not fill the cache", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		loader := cache.NewLoader(cacher, heldLocker{}, 100*time.Millisecond)
+
+		res, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+			return "loaded", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "loaded", res)
+	})
+}
+
+func TestGetOrLoad_StaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	loader := cache.NewRefreshingLoader(cacher, nil, time.Second, goboilerplate.ExpiryConf{
+		goboilerplate.DurationShort: {TTL: 20 * time.Millisecond},
+	}, 1)
+
+	var calls int32
+	load := func(ctx context.Context) (string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return "v1", nil
+		}
+		return "v2", nil
+	}
+
+	res, err := loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, load)
+	require.NoError(t, err)
+	require.Equal(t, "v1", res)
+
+	time.Sleep(30 * time.Millisecond)
+
+	// the stale value is served while being refreshed
+	res, err = loader.GetOrLoad(ctx, "key", goboilerplate.DurationShort, load)
+	require.NoError(t, err)
+	require.Equal(t, "v1", res)
+
+	require.Eventually(t, func() bool {
+		res, err := loader.Get(ctx, "key")
+		return err == nil && res == "v2"
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestGetOrLoad_CancelledCaller(t *testing.T) {
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	loader := cache.NewLoader(cacher, nil, time.Second)
+
+	load := func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return "loaded", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	// the first caller gives up, while the second one still gets the value of the shared load
+	firstCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := loader.GetOrLoad(firstCtx, "key", goboilerplate.DurationShort, load)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	res, err := loader.GetOrLoad(context.Background(), "key", goboilerplate.DurationShort, load)
+	require.NoError(t, err)
+	require.Equal(t, "loaded", res)
+	wg.Wait()
+
+	res, err = cacher.Get(context.Background(), "key")
+	require.NoError(t, err)
+	require.Equal(t, "loaded", res)
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// notFoundMarker is the value stored for keys known to be missing.
+const notFoundMarker = "\x00not-found"
+
+type negativeCacher struct {
+	goboilerplate.Cacher
+}
+
+// NewNegativeCacher is a constructor for a goboilerplate.NegativeCacher on top of the cacher.
+// The returned cacher also implements goboilerplate.BatchCacher, goboilerplate.ProgressFlusher,
+// goboilerplate.TagCacher and goboilerplate.NamespaceCacher on top of cacher, see the helpers of the same name for
+// the fallbacks.
+func NewNegativeCacher(cacher goboilerplate.Cacher) goboilerplate.NegativeCacher {
+	return negativeCacher{
+		Cacher: cacher,
+	}
+}
+
+func (c negativeCacher) Get(ctx context.Context, key string) (value string, err error) {
+	value, err = c.Cacher.Get(ctx, key)
+	if err != nil {
+		return
+	}
+
+	if value == notFoundMarker {
+		value, err = "", goboilerplate.ErrNegativeCached
+		return
+	}
+	return
+}
+
+func (c negativeCacher) SetNotFound(ctx context.Context, key string) error {
+	return c.Cacher.Set(ctx, key, notFoundMarker, goboilerplate.DurationNegative)
+}
+
+// MGet gets the keys, those known to be missing having ErrNegativeCached as their Err.
+func (c negativeCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = goboilerplate.MGet(ctx, c.Cacher, keys...)
+	for i := range results {
+		if results[i].Err == nil && results[i].Value == notFoundMarker {
+			results[i].Value, results[i].Err = "", goboilerplate.ErrNegativeCached
+		}
+	}
+	return
+}
+
+func (c negativeCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) []goboilerplate.BatchResult {
+	return goboilerplate.MSet(ctx, c.Cacher, items, expiration)
+}
+
+func (c negativeCacher) MDel(ctx context.Context, keys ...string) []goboilerplate.BatchResult {
+	return goboilerplate.MDel(ctx, c.Cacher, keys...)
+}
+
+func (c negativeCacher) FlushWithProgress(
+	ctx context.Context,
+	progress goboilerplate.FlushProgressFunc,
+) (int64, error) {
+	return goboilerplate.FlushWithProgress(ctx, c.Cacher, progress)
+}
+
+func (c negativeCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) error {
+	return goboilerplate.SetWithTags(ctx, c.Cacher, key, value, expiration, tags...)
+}
+
+func (c negativeCacher) InvalidateTags(ctx context.Context, tags ...string) error {
+	return goboilerplate.InvalidateTags(ctx, c.Cacher, tags...)
+}
+
+func (c negativeCacher) BumpNamespace(ctx context.Context, namespace string) (int64, error) {
+	return goboilerplate.BumpNamespace(ctx, c.Cacher, namespace)
+}
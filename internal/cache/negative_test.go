@@ -0,0 +1,100 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+func TestNegativeCacher(t *testing.T) {
+	ctx := context.Background()
+	cacher := cache.NewNegativeCacher(memory.NewMemoryCacher(goboilerplate.ExpiryConf{
+		goboilerplate.DurationNegative: {TTL: 10 * time.Millisecond},
+	}, 0, 0))
+
+	require.NoError(t, cacher.SetNotFound(ctx, "missing"))
+
+	_, err := cacher.Get(ctx, "missing")
+	require.Equal(t, goboilerplate.ErrNegativeCached, err)
+	require.Equal(t, goboilerplate.ErrNotFound, errors.Cause(err))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = cacher.Get(ctx, "missing")
+	require.Equal(t, goboilerplate.ErrNotFound, err)
+}
+
+// annotatingCacher annotates the errors of Get, like the decorators adding context to them.
+type annotatingCacher struct {
+	goboilerplate.NegativeCacher
+}
+
+func (c annotatingCacher) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.NegativeCacher.Get(ctx, key)
+	return value, errors.Wrap(err, "annotated")
+}
+
+func TestGetOrLoad_NegativeCaching(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		cacher   goboilerplate.Cacher
+		notFound error
+	}{
+		"pkg/errors wrapped": {
+			cacher:   cache.NewNegativeCacher(memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)),
+			notFound: errors.Wrap(goboilerplate.ErrNotFound, "article not found"),
+		},
+		"%w wrapped": {
+			cacher:   cache.NewNegativeCacher(memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)),
+			notFound: fmt.Errorf("article %s: %w", "missing", goboilerplate.ErrNotFound),
+		},
+		"annotated by the cacher": {
+			cacher: annotatingCacher{
+				cache.NewNegativeCacher(memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)),
+			},
+			notFound: goboilerplate.ErrNotFound,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			loader := cache.NewLoader(test.cacher, nil, 0)
+			calls := 0
+			notFound := func(ctx context.Context) (string, error) {
+				calls++
+				return "", test.notFound
+			}
+
+			_, err := loader.GetOrLoad(ctx, "missing", goboilerplate.DurationShort, notFound)
+			require.ErrorIs(t, err, goboilerplate.ErrNotFound)
+
+			_, err = loader.GetOrLoad(ctx, "missing", goboilerplate.DurationShort, notFound)
+			require.ErrorIs(t, err, goboilerplate.ErrNegativeCached)
+			require.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestNegativeCacher_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	cacher := cache.NewNegativeCacher(memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0))
+
+	// the memory cacher has no namespaces
+	_, err := cacher.(goboilerplate.NamespaceCacher).BumpNamespace(ctx, "article")
+	require.Equal(t, goboilerplate.ErrNotSupported, errors.Cause(err))
+
+	require.NoError(t, cacher.SetNotFound(ctx, "article:2"))
+	require.Equal(t, []goboilerplate.BatchResult{
+		{Key: "article:1", Err: goboilerplate.ErrNotFound},
+		{Key: "article:2", Err: goboilerplate.ErrNegativeCached},
+	}, cacher.(goboilerplate.BatchCacher).MGet(ctx, "article:1", "article:2"))
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+const instrumentationName = "github.com/kurio/boilerplate-go/internal/cache"
+
+// Codec is the interface of the serialization used to store typed values in a Cacher.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values as JSON.
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes values with encoding/gob.
+type GobCodec struct{}
+
+func (GobCodec) Name() string {
+	return "gob"
+}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// TypedCache stores values of type T in a Cacher through a Codec.
+// Cached entries that can not be decoded are treated as a miss: they are deleted and counted in the
+// cache.decode_errors metric. A freshly loaded value that can not be decoded is an error.
+type TypedCache[T any] struct {
+	cacher goboilerplate.Cacher
+	loader *Loader
+	codec  Codec
+
+	decodeErrors syncint64.Counter
+}
+
+// NewTypedCache is a constructor for TypedCache, using the cacher behind loader.
+// If codec is nil, JSONCodec is used. The global meter provider is used when meterProvider is nil.
+func NewTypedCache[T any](loader *Loader, codec Codec, meterProvider metric.MeterProvider) *TypedCache[T] {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	if meterProvider == nil {
+		meterProvider = global.MeterProvider()
+	}
+
+	decodeErrors, err := meterProvider.Meter(instrumentationName).SyncInt64().Counter(
+		"cache.decode_errors",
+		instrument.WithDescription("The number of cache entries that could not be decoded."),
+	)
+	if err != nil {
+		logrus.Errorf("Error creating cache.decode_errors counter: %+v", err)
+	}
+
+	return &TypedCache[T]{
+		cacher:       loader.cacher,
+		loader:       loader,
+		codec:        codec,
+		decodeErrors: decodeErrors,
+	}
+}
+
+func (c *TypedCache[T]) Get(ctx context.Context, key string) (value T, err error) {
+	var str string
+	str, err = c.loader.Get(ctx, key)
+	if err != nil {
+		return
+	}
+
+	value, err = c.decode(key, str)
+	if err != nil {
+		c.dropCorrupt(ctx, key, err)
+		err = goboilerplate.ErrNotFound
+	}
+	return
+}
+
+func (c *TypedCache[T]) Set(
+	ctx context.Context,
+	key string,
+	value T,
+	expiration goboilerplate.ExpiryDuration,
+) (err error) {
+	var data []byte
+	data, err = c.codec.Marshal(value)
+	if err != nil {
+		err = errors.Wrapf(err, "error encoding '%s' with %s", key, c.codec.Name())
+		return
+	}
+
+	err = c.loader.Set(ctx, key, string(data), expiration)
+	return
+}
+
+func (c *TypedCache[T]) Del(ctx context.Context, key string) error {
+	return c.cacher.Del(ctx, key)
+}
+
+// GetOrLoad gets the value of key, loading and caching it with loader on a miss. See Loader.GetOrLoad.
+func (c *TypedCache[T]) GetOrLoad(
+	ctx context.Context,
+	key string,
+	expiration goboilerplate.ExpiryDuration,
+	loader func(ctx context.Context) (T, error),
+) (value T, err error) {
+	load := func(ctx context.Context) (string, error) {
+		value, err := loader(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return "", errors.Wrapf(err, "error encoding '%s' with %s", key, c.codec.Name())
+		}
+		return string(data), nil
+	}
+
+	var str string
+	str, err = c.loader.GetOrLoad(ctx, key, expiration, load)
+	if err != nil {
+		return
+	}
+
+	value, err = c.decode(key, str)
+	if err == nil {
+		return
+	}
+	c.dropCorrupt(ctx, key, err)
+
+	// the corrupt entry has been deleted, so this loads a fresh value, whose decoding error is returned as is since
+	// it's a bug of the codec or of T rather than a corrupt entry
+	str, err = c.loader.GetOrLoad(ctx, key, expiration, load)
+	if err != nil {
+		return
+	}
+
+	value, err = c.decode(key, str)
+	return
+}
+
+// decode decodes str, the value of key.
+func (c *TypedCache[T]) decode(key string, str string) (value T, err error) {
+	if err = c.codec.Unmarshal([]byte(str), &value); err != nil {
+		err = errors.Wrapf(err, "error decoding '%s' with %s", key, c.codec.Name())
+	}
+	return
+}
+
+// dropCorrupt deletes the entry of key that could not be decoded.
+func (c *TypedCache[T]) dropCorrupt(ctx context.Context, key string, err error) {
+	logrus.Warnf("Deleting corrupt entry '%s' from cache: %+v", key, err)
+	if c.decodeErrors != nil {
+		c.decodeErrors.Add(ctx, 1, attribute.String("codec", c.codec.Name()))
+	}
+
+	if err := c.cacher.Del(ctx, key); err != nil && !errors.Is(err, goboilerplate.ErrNotFound) {
+		logrus.Warnf("Error deleting corrupt entry '%s' from cache: %+v", key, err)
+	}
+}
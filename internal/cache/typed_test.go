@@ -0,0 +1,128 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+type article struct {
+	ID    string
+	Title string
+}
+
+func TestTypedCache(t *testing.T) {
+	ctx := context.Background()
+
+	codecs := map[string]cache.Codec{
+		"json": cache.JSONCodec{},
+		"gob":  cache.GobCodec{},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+			articles := cache.NewTypedCache[article](cache.NewLoader(cacher, nil, 0), codec, nil)
+
+			expected := article{ID: "1", Title: "some title"}
+			require.NoError(t, articles.Set(ctx, "article", expected, goboilerplate.DurationShort))
+
+			res, err := articles.Get(ctx, "article")
+			require.NoError(t, err)
+			require.Equal(t, expected, res)
+
+			require.NoError(t, articles.Del(ctx, "article"))
+			_, err = articles.Get(ctx, "article")
+			require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+		})
+	}
+}
+
+func TestTypedCache_Corrupt(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		articles := cache.NewTypedCache[article](cache.NewLoader(cacher, nil, 0), nil, meterProvider)
+
+		require.NoError(t, cacher.Set(ctx, "article", "{corrupt", goboilerplate.DurationShort))
+
+		_, err := articles.Get(ctx, "article")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+
+		_, err = cacher.Get(ctx, "article")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+		require.Equal(t, int64(1), decodeErrors(t, reader))
+	})
+
+	t.Run("get or load", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		articles := cache.NewTypedCache[article](cache.NewLoader(cacher, nil, 0), nil, nil)
+
+		require.NoError(t, cacher.Set(ctx, "article", "{corrupt", goboilerplate.DurationShort))
+
+		expected := article{ID: "1"}
+		load := func(ctx context.Context) (article, error) {
+			return expected, nil
+		}
+		res, err := articles.GetOrLoad(ctx, "article", goboilerplate.DurationShort, load)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+
+		res, err = articles.Get(ctx, "article")
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	})
+}
+
+// undecodableCodec encodes values it can not decode, like a codec or a type with a bug.
+type undecodableCodec struct {
+	cache.JSONCodec
+}
+
+func (undecodableCodec) Unmarshal(data []byte, v interface{}) error {
+	return errors.New("unexpected field")
+}
+
+func TestTypedCache_Undecodable(t *testing.T) {
+	ctx := context.Background()
+
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	articles := cache.NewTypedCache[article](cache.NewLoader(cacher, nil, 0), undecodableCodec{}, nil)
+
+	_, err := articles.GetOrLoad(ctx, "article", goboilerplate.DurationShort, func(ctx context.Context) (article, error) {
+		return article{ID: "1"}, nil
+	})
+	require.Error(t, err)
+	require.NotEqual(t, goboilerplate.ErrNotFound, errors.Cause(err))
+	require.Contains(t, err.Error(), "error decoding 'article' with json")
+}
+
+// decodeErrors returns the cache.decode_errors count.
+func decodeErrors(t *testing.T, reader sdkmetric.Reader) (count int64) {
+	t.Helper()
+
+	rm, err := reader.Collect(context.Background())
+	require.NoError(t, err)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "cache.decode_errors" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				count += dp.Value
+			}
+		}
+	}
+	return
+}
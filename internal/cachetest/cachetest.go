@@ -0,0 +1,172 @@
+// Package cachetest is a conformance suite for the goboilerplate.Cacher implementations.
+package cachetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// Harness builds the cachers under test.
+type Harness struct {
+	// New returns a cacher using expiryConf. Its entries must be isolated from those of the cachers returned with
+	// another keyPrefix, even if they share the same storage.
+	New func(t *testing.T, expiryConf goboilerplate.ExpiryConf, keyPrefix string) goboilerplate.Cacher
+	// Advance moves the clock of the cachers forward. It sleeps when nil, for the cachers using the system clock.
+	Advance func(t *testing.T, d time.Duration)
+}
+
+const shortTTL = 100 * time.Millisecond
+
+// keys are both plain and namespaced, for the cachers treating the part before ':' specially.
+var keys = []string{"some-key", "article:42"}
+
+// Run runs the conformance suite against the cachers built by h.
+func Run(t *testing.T, h Harness) {
+	require.NotNil(t, h.New)
+	if h.Advance == nil {
+		h.Advance = func(t *testing.T, d time.Duration) {
+			time.Sleep(d)
+		}
+	}
+
+	t.Run("get", func(t *testing.T) { testGet(t, h) })
+	t.Run("del", func(t *testing.T) { testDel(t, h) })
+	t.Run("expiry", func(t *testing.T) { testExpiry(t, h) })
+	t.Run("prefix isolation", func(t *testing.T) { testPrefixIsolation(t, h) })
+	t.Run("flush", func(t *testing.T) { testFlush(t, h) })
+}
+
+func requireNotFound(t *testing.T, err error) {
+	t.Helper()
+
+	require.Error(t, err)
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+}
+
+func testGet(t *testing.T, h Harness) {
+	ctx := context.Background()
+	cacher := h.New(t, goboilerplate.ExpiryConf{}, "cachetest-get")
+
+	for _, key := range keys {
+		_, err := cacher.Get(ctx, key)
+		requireNotFound(t, err)
+
+		require.NoError(t, cacher.Set(ctx, key, "some-value", goboilerplate.DurationShort))
+		value, err := cacher.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "some-value", value)
+
+		require.NoError(t, cacher.Set(ctx, key, "other-value", goboilerplate.DurationShort))
+		value, err = cacher.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "other-value", value)
+	}
+}
+
+func testDel(t *testing.T, h Harness) {
+	ctx := context.Background()
+	cacher := h.New(t, goboilerplate.ExpiryConf{}, "cachetest-del")
+
+	for _, key := range keys {
+		requireNotFound(t, cacher.Del(ctx, key))
+
+		require.NoError(t, cacher.Set(ctx, key, "some-value", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Del(ctx, key))
+
+		_, err := cacher.Get(ctx, key)
+		requireNotFound(t, err)
+		requireNotFound(t, cacher.Del(ctx, key))
+	}
+}
+
+func testExpiry(t *testing.T, h Harness) {
+	ctx := context.Background()
+	cacher := h.New(t, goboilerplate.ExpiryConf{
+		goboilerplate.DurationShort: {TTL: shortTTL},
+		goboilerplate.DurationLong:  {TTL: time.Hour},
+	}, "cachetest-expiry")
+
+	for _, key := range keys {
+		require.NoError(t, cacher.Set(ctx, key+"-short", "v", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Set(ctx, key+"-long", "v", goboilerplate.DurationLong))
+		// unknown tiers fall back to DurationShort
+		require.NoError(t, cacher.Set(ctx, key+"-unknown", "v", goboilerplate.ExpiryDuration("unknown")))
+	}
+
+	h.Advance(t, shortTTL/2)
+	for _, key := range keys {
+		_, err := cacher.Get(ctx, key+"-short")
+		require.NoError(t, err)
+	}
+
+	h.Advance(t, shortTTL)
+	for _, key := range keys {
+		_, err := cacher.Get(ctx, key+"-short")
+		requireNotFound(t, err)
+		_, err = cacher.Get(ctx, key+"-unknown")
+		requireNotFound(t, err)
+		requireNotFound(t, cacher.Del(ctx, key+"-unknown"))
+
+		value, err := cacher.Get(ctx, key+"-long")
+		require.NoError(t, err)
+		require.Equal(t, "v", value)
+	}
+}
+
+func testPrefixIsolation(t *testing.T, h Harness) {
+	ctx := context.Background()
+	a := h.New(t, goboilerplate.ExpiryConf{}, "cachetest-isolation-a")
+	b := h.New(t, goboilerplate.ExpiryConf{}, "cachetest-isolation-b")
+
+	for _, key := range keys {
+		require.NoError(t, a.Set(ctx, key, "a", goboilerplate.DurationShort))
+
+		_, err := b.Get(ctx, key)
+		requireNotFound(t, err)
+		requireNotFound(t, b.Del(ctx, key))
+
+		require.NoError(t, b.Set(ctx, key, "b", goboilerplate.DurationShort))
+		value, err := a.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "a", value)
+
+		require.NoError(t, b.Del(ctx, key))
+		value, err = a.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "a", value)
+	}
+}
+
+func testFlush(t *testing.T, h Harness) {
+	ctx := context.Background()
+	a := h.New(t, goboilerplate.ExpiryConf{}, "cachetest-flush-a")
+	b := h.New(t, goboilerplate.ExpiryConf{}, "cachetest-flush-b")
+
+	for _, key := range keys {
+		require.NoError(t, a.Set(ctx, key, "a", goboilerplate.DurationShort))
+		require.NoError(t, b.Set(ctx, key, "b", goboilerplate.DurationShort))
+	}
+
+	require.NoError(t, a.Flush(ctx))
+
+	for _, key := range keys {
+		_, err := a.Get(ctx, key)
+		requireNotFound(t, err)
+
+		value, err := b.Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, "b", value)
+	}
+
+	// the cacher is still usable after flushing
+	require.NoError(t, a.Set(ctx, keys[0], "a", goboilerplate.DurationShort))
+	value, err := a.Get(ctx, keys[0])
+	require.NoError(t, err)
+	require.Equal(t, "a", value)
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Admin configuration
+type Admin struct {
+	Enabled bool
+	// Token is the bearer token required by the admin routes.
+	Token string
+}
+
+func loadAdminConfig() Admin {
+	viper.SetDefault("admin.enabled", false)
+
+	c := Admin{
+		Enabled: viper.GetBool("admin.enabled"),
+		Token:   viper.GetString("admin.token"),
+	}
+	if c.Enabled && c.Token == "" {
+		logrus.Fatal("admin.token is required when admin.enabled is set")
+	}
+
+	return c
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Cache configuration
+type Cache struct {
+	// ExpiryTiers are the named expiry tiers besides redis.short_expiration_time and redis.long_expiration_time.
+	ExpiryTiers map[string]ExpiryTier
+	// ExpiryJitterPercent is the jitter of the tiers not defining their own.
+	ExpiryJitterPercent float64
+
+	Local           LocalCache
+	Refresh         CacheRefresh
+	Breaker         CacheBreaker
+	Compression     CacheCompression
+	Encryption      CacheEncryption
+	LoadLockTimeout time.Duration
+	// NamespaceVersionCacheTTL is how long the namespace versions are cached locally, i.e. how long other instances
+	// keep serving a namespace after it is bumped.
+	NamespaceVersionCacheTTL time.Duration
+}
+
+// ExpiryTier is the configuration of a named expiry tier.
+type ExpiryTier struct {
+	TTL           time.Duration
+	JitterPercent float64
+}
+
+// LocalCache is the configuration of the in-process cache put in front of redis.
+type LocalCache struct {
+	Enabled        bool
+	MaxEntries     int
+	MaxBytes       int
+	ExpirationTime time.Duration
+}
+
+// CacheRefresh is the configuration of stale-while-revalidate.
+type CacheRefresh struct {
+	Enabled bool
+	// SoftExpirationRatio is the soft expiry of an entry relative to its expiry, e.g. 0.8 of a minute.
+	SoftExpirationRatio float64
+	// Beta tunes how early entries are refreshed, with 1 as the default and greater values refreshing earlier.
+	Beta float64
+}
+
+// CacheBreaker is the configuration of the circuit breaker around redis.
+type CacheBreaker struct {
+	Enabled bool
+	// FailureThreshold is the number of consecutive failed or slow calls tripping the breaker.
+	FailureThreshold int
+	// SlowThreshold is the duration above which a call counts as failed.
+	SlowThreshold time.Duration
+	// Timeout bounds every call, way below the redis read timeout.
+	Timeout time.Duration
+	// ProbeInterval is the interval between the recovery probes while the breaker is open.
+	ProbeInterval time.Duration
+}
+
+// CacheCompression is the configuration of the compression of the values stored in redis.
+type CacheCompression struct {
+	// Algorithm is one of "none", "gzip" or "snappy".
+	Algorithm      string
+	ThresholdBytes int
+}
+
+// CacheEncryption is the configuration of the encryption of the values stored in redis.
+type CacheEncryption struct {
+	// Keys are the AES keys by version. Rotate them by adding a new version and making it current, and drop the old
+	// one once its entries have expired.
+	Keys map[uint8][]byte
+	// CurrentKeyVersion is the version of the key encrypting new values, 0 disabling the encryption.
+	CurrentKeyVersion uint8
+}
+
+func loadCacheConfig() Cache {
+	viper.SetDefault("cache.expiry_jitter_percent", 0)
+	viper.SetDefault("cache.local.enabled", false)
+	viper.SetDefault("cache.local.max_entries", 10000)
+	viper.SetDefault("cache.local.max_bytes", 64<<20)
+	viper.SetDefault("cache.local.expiration_time_ms", 5000)
+	viper.SetDefault("cache.load_lock_timeout_ms", 5000)
+	viper.SetDefault("cache.namespace_version_cache_ttl_ms", 1000)
+	viper.SetDefault("cache.refresh.enabled", false)
+	viper.SetDefault("cache.refresh.soft_expiration_ratio", 0.8)
+	viper.SetDefault("cache.refresh.beta", 1.0)
+	viper.SetDefault("cache.breaker.enabled", false)
+	viper.SetDefault("cache.breaker.failure_threshold", 5)
+	viper.SetDefault("cache.breaker.slow_threshold_ms", 200)
+	viper.SetDefault("cache.breaker.timeout_ms", 500)
+	viper.SetDefault("cache.breaker.probe_interval_ms", 1000)
+	viper.SetDefault("cache.compression.algorithm", "none")
+	viper.SetDefault("cache.compression.threshold_bytes", 1024)
+	viper.SetDefault("cache.encryption.current_key_version", 0)
+
+	expiryJitterPercent := viper.GetFloat64("cache.expiry_jitter_percent")
+	if !validJitterPercent(expiryJitterPercent) {
+		logrus.Fatalf("cache.expiry_jitter_percent must be in [0, 100), got %v", expiryJitterPercent)
+	}
+
+	expiryTiers, err := loadExpiryTiers(viper.GetViper(), expiryJitterPercent)
+	if err != nil {
+		logrus.Fatalf("Error loading cache.expiry_tiers: %+v", err)
+	}
+
+	softExpirationRatio := viper.GetFloat64("cache.refresh.soft_expiration_ratio")
+	if softExpirationRatio <= 0 || softExpirationRatio > 1 {
+		logrus.Fatalf("cache.refresh.soft_expiration_ratio must be in (0, 1], got %v", softExpirationRatio)
+	}
+
+	breakerFailureThreshold := viper.GetInt("cache.breaker.failure_threshold")
+	if breakerFailureThreshold <= 0 {
+		logrus.Fatalf("cache.breaker.failure_threshold must be positive, got %d", breakerFailureThreshold)
+	}
+
+	compressionAlgorithm := strings.ToLower(viper.GetString("cache.compression.algorithm"))
+	switch compressionAlgorithm {
+	case "none", "gzip", "snappy":
+	default:
+		logrus.Fatalf("cache.compression.algorithm must be one of none, gzip or snappy, got '%s'", compressionAlgorithm)
+	}
+
+	encryption, err := loadEncryption(viper.GetViper())
+	if err != nil {
+		logrus.Fatalf("Error loading cache.encryption: %+v", err)
+	}
+
+	return Cache{
+		ExpiryTiers:         expiryTiers,
+		ExpiryJitterPercent: expiryJitterPercent,
+		Local: LocalCache{
+			Enabled:        viper.GetBool("cache.local.enabled"),
+			MaxEntries:     viper.GetInt("cache.local.max_entries"),
+			MaxBytes:       viper.GetInt("cache.local.max_bytes"),
+			ExpirationTime: time.Duration(viper.GetInt("cache.local.expiration_time_ms")) * time.Millisecond,
+		},
+		Refresh: CacheRefresh{
+			Enabled:             viper.GetBool("cache.refresh.enabled"),
+			SoftExpirationRatio: softExpirationRatio,
+			Beta:                viper.GetFloat64("cache.refresh.beta"),
+		},
+		Breaker: CacheBreaker{
+			Enabled:          viper.GetBool("cache.breaker.enabled"),
+			FailureThreshold: breakerFailureThreshold,
+			SlowThreshold:    time.Duration(viper.GetInt("cache.breaker.slow_threshold_ms")) * time.Millisecond,
+			Timeout:          time.Duration(viper.GetInt("cache.breaker.timeout_ms")) * time.Millisecond,
+			ProbeInterval:    time.Duration(viper.GetInt("cache.breaker.probe_interval_ms")) * time.Millisecond,
+		},
+		Compression: CacheCompression{
+			Algorithm:      compressionAlgorithm,
+			ThresholdBytes: viper.GetInt("cache.compression.threshold_bytes"),
+		},
+		Encryption:               encryption,
+		LoadLockTimeout:          time.Duration(viper.GetInt("cache.load_lock_timeout_ms")) * time.Millisecond,
+		NamespaceVersionCacheTTL: time.Duration(viper.GetInt("cache.namespace_version_cache_ttl_ms")) * time.Millisecond,
+	}
+}
+
+// expiryTierConfig is the configuration of an expiry tier, e.g.
+// {"cache": {"expiry_tiers": {"medium": {"ttl_ms": 600000, "jitter_percent": 10}}}}.
+type expiryTierConfig struct {
+	TTLMs         int      `mapstructure:"ttl_ms"`
+	JitterPercent *float64 `mapstructure:"jitter_percent"`
+}
+
+// validJitterPercent tells whether the jitter keeps the TTL above 0.
+func validJitterPercent(jitterPercent float64) bool {
+	return jitterPercent >= 0 && jitterPercent < 100
+}
+
+// loadExpiryTiers loads the expiry tiers of cache.expiry_tiers, the tiers without jitter_percent using
+// defaultJitterPercent. Viper keys being case insensitive, the tier names are lower case.
+func loadExpiryTiers(v *viper.Viper, defaultJitterPercent float64) (tiers map[string]ExpiryTier, err error) {
+	var confs map[string]expiryTierConfig
+	if err = v.UnmarshalKey("cache.expiry_tiers", &confs); err != nil {
+		err = errors.Wrap(err, "invalid expiry tiers")
+		return
+	}
+
+	tiers = make(map[string]ExpiryTier, len(confs))
+	for name, conf := range confs {
+		if conf.TTLMs <= 0 {
+			err = errors.Errorf("ttl_ms of expiry tier '%s' must be positive", name)
+			return
+		}
+
+		tier := ExpiryTier{
+			TTL:           time.Duration(conf.TTLMs) * time.Millisecond,
+			JitterPercent: defaultJitterPercent,
+		}
+		if conf.JitterPercent != nil {
+			if !validJitterPercent(*conf.JitterPercent) {
+				err = errors.Errorf("jitter_percent of expiry tier '%s' must be in [0, 100)", name)
+				return
+			}
+			tier.JitterPercent = *conf.JitterPercent
+		}
+
+		tiers[name] = tier
+	}
+	return
+}
+
+// loadEncryption loads the keys of cache.encryption.keys by version, with versions in [1, 255] and base64 keys of
+// 16, 24 or 32 bytes, e.g. {"cache": {"encryption": {"current_key_version": 2, "keys": {"1": "q83v...", "2": "..."}}}}.
+func loadEncryption(v *viper.Viper) (encryption CacheEncryption, err error) {
+	var encodedKeys map[string]string
+	if err = v.UnmarshalKey("cache.encryption.keys", &encodedKeys); err != nil {
+		err = errors.Wrap(err, "invalid encryption keys")
+		return
+	}
+
+	encryption.Keys = make(map[uint8][]byte, len(encodedKeys))
+	for versionStr, encoded := range encodedKeys {
+		var version uint64
+		version, err = strconv.ParseUint(versionStr, 10, 8)
+		if err != nil || version == 0 {
+			err = errors.Errorf("invalid encryption key version '%s', expected [1, 255]", versionStr)
+			return
+		}
+
+		var key []byte
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			err = errors.Wrapf(err, "invalid encryption key version %d", version)
+			return
+		}
+		if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+			err = errors.Errorf("encryption key version %d must be 16, 24 or 32 bytes, got %d", version, len(key))
+			return
+		}
+
+		encryption.Keys[uint8(version)] = key
+	}
+
+	currentKeyVersion := v.GetInt("cache.encryption.current_key_version")
+	if currentKeyVersion < 0 || currentKeyVersion > 255 {
+		err = errors.Errorf("invalid current_key_version %d, expected [0, 255]", currentKeyVersion)
+		return
+	}
+	encryption.CurrentKeyVersion = uint8(currentKeyVersion)
+	if _, ok := encryption.Keys[encryption.CurrentKeyVersion]; currentKeyVersion != 0 && !ok {
+		err = errors.Errorf("current_key_version %d is not in the keys", currentKeyVersion)
+		return
+	}
+	return
+}
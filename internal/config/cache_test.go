@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// newViper returns a viper reading the JSON config.
+func newViper(t *testing.T, config string) *viper.Viper {
+	t.Helper()
+
+	v := viper.New()
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(strings.NewReader(config)))
+	return v
+}
+
+func TestLoadExpiryTiers(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expected    map[string]ExpiryTier
+		expectedErr bool
+	}{
+		"empty": {
+			input:    `{}`,
+			expected: map[string]ExpiryTier{},
+		},
+		"with and without jitter": {
+			input: `{"cache": {"expiry_tiers": {
+				"medium": {"ttl_ms": 600000, "jitter_percent": 10},
+				"negative": {"ttl_ms": 30000}
+			}}}`,
+			expected: map[string]ExpiryTier{
+				"medium":   {TTL: 10 * time.Minute, JitterPercent: 10},
+				"negative": {TTL: 30 * time.Second, JitterPercent: 5},
+			},
+		},
+		"missing ttl": {
+			input:       `{"cache": {"expiry_tiers": {"medium": {"jitter_percent": 10}}}}`,
+			expectedErr: true,
+		},
+		"invalid ttl": {
+			input:       `{"cache": {"expiry_tiers": {"medium": {"ttl_ms": "10m"}}}}`,
+			expectedErr: true,
+		},
+		"invalid jitter": {
+			input:       `{"cache": {"expiry_tiers": {"medium": {"ttl_ms": 600000, "jitter_percent": 150}}}}`,
+			expectedErr: true,
+		},
+		"jitter reaching 0": {
+			input:       `{"cache": {"expiry_tiers": {"medium": {"ttl_ms": 600000, "jitter_percent": 100}}}}`,
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res, err := loadExpiryTiers(newViper(t, test.input), 5)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestLoadEncryption(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expected    CacheEncryption
+		expectedErr bool
+	}{
+		"empty": {
+			input:    `{}`,
+			expected: CacheEncryption{Keys: map[uint8][]byte{}},
+		},
+		"rotated keys": {
+			input: `{"cache": {"encryption": {"current_key_version": 2, "keys": {
+				"1": "AQEBAQEBAQEBAQEBAQEBAQ==",
+				"2": "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+			}}}}`,
+			expected: CacheEncryption{
+				Keys: map[uint8][]byte{
+					1: bytes.Repeat([]byte{1}, 16),
+					2: bytes.Repeat([]byte{2}, 32),
+				},
+				CurrentKeyVersion: 2,
+			},
+		},
+		"reserved version": {
+			input:       `{"cache": {"encryption": {"keys": {"0": "AQEBAQEBAQEBAQEBAQEBAQ=="}}}}`,
+			expectedErr: true,
+		},
+		"version above 255": {
+			input:       `{"cache": {"encryption": {"keys": {"257": "AQEBAQEBAQEBAQEBAQEBAQ=="}}}}`,
+			expectedErr: true,
+		},
+		"current version above 255": {
+			input: `{"cache": {"encryption": {"current_key_version": 257, "keys": {
+				"1": "AQEBAQEBAQEBAQEBAQEBAQ=="
+			}}}}`,
+			expectedErr: true,
+		},
+		"unknown current version": {
+			input: `{"cache": {"encryption": {"current_key_version": 2, "keys": {
+				"1": "AQEBAQEBAQEBAQEBAQEBAQ=="
+			}}}}`,
+			expectedErr: true,
+		},
+		"invalid base64": {
+			input:       `{"cache": {"encryption": {"keys": {"1": "not base64"}}}}`,
+			expectedErr: true,
+		},
+		"invalid key size": {
+			input:       `{"cache": {"encryption": {"keys": {"1": "AQEB"}}}}`,
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res, err := loadEncryption(newViper(t, test.input))
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
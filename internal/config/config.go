@@ -13,8 +13,12 @@ type Config struct {
 	MySQL MySQL
 	Mongo Mongo
 	Redis Redis
+	Cache Cache
 	HTTP  HTTP
 
+	RateLimit RateLimit
+	Admin     Admin
+
 	Otel Otel
 }
 
@@ -31,7 +35,10 @@ func LoadConfig() Config {
 	c.MySQL = loadMySQLConfig()
 	c.Mongo = loadMongoConfig()
 	c.Redis = loadRedisConfig()
+	c.Cache = loadCacheConfig()
 	c.HTTP = loadHTTPConfig()
+	c.RateLimit = loadRateLimitConfig()
+	c.Admin = loadAdminConfig()
 
 	c.Otel = loadOtelConfig()
 
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"net"
+	"regexp"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
@@ -10,6 +14,27 @@ type httpServer struct {
 	Timeout      time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+
+	// ProblemJSON always replies errors as application/problem+json, not only when accepted by the client.
+	ProblemJSON        bool
+	ProblemTypeBaseURI string
+
+	// LogRequests logs every request, its URI redacted.
+	LogRequests bool
+
+	// TrustedProxies are the IP ranges of the proxies whose X-Forwarded-For is trusted to get the client IP. Without
+	// them, the client IP is the remote address.
+	TrustedProxies []*net.IPNet
+}
+
+// httpRedaction is added to the default redaction policy of the logged requests and errors.
+type httpRedaction struct {
+	// DeniedHeaders are masked on top of the default ones.
+	DeniedHeaders []string
+	// AllowedHeaders are the only headers logged when set, the others being masked.
+	AllowedHeaders []string
+	// ValueMasks are masked on top of the default ones, only their first capturing group if any.
+	ValueMasks []*regexp.Regexp
 }
 
 type httpClient struct {
@@ -20,25 +45,45 @@ type httpClient struct {
 }
 
 type HTTP struct {
-	Server httpServer
-	Client httpClient
+	Server    httpServer
+	Client    httpClient
+	Redaction httpRedaction
 }
 
 func loadHTTPConfig() HTTP {
 	viper.SetDefault("http.server.timeout_ms", 2000)
 	viper.SetDefault("http.server.read_timeout_ms", 0)
 	viper.SetDefault("http.server.write_timeout_ms", 0)
+	viper.SetDefault("http.server.problem_json", false)
+	viper.SetDefault("http.server.log_requests", false)
 
 	viper.SetDefault("http.client.timeout_ms", 3000)
 	viper.SetDefault("http.client.max_idle_conns", 100)
 	viper.SetDefault("http.client.max_idle_conns_per_host", 2)
 	viper.SetDefault("http.client.idle_conn_timeout", 90)
 
+	valueMasks, err := parseValueMasks(viper.GetStringSlice("http.redaction.value_masks"))
+	if err != nil {
+		logrus.Fatalf("Error parsing http.redaction.value_masks: %+v", err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(viper.GetStringSlice("http.server.trusted_proxies"))
+	if err != nil {
+		logrus.Fatalf("Error parsing http.server.trusted_proxies: %+v", err)
+	}
+
 	return HTTP{
 		Server: httpServer{
 			Timeout:      time.Duration(viper.GetInt("http.server.timeout_ms")) * time.Millisecond,
 			ReadTimeout:  time.Duration(viper.GetInt("http.server.read_timeout_ms")) * time.Millisecond,
 			WriteTimeout: time.Duration(viper.GetInt("http.server.write_timeout_ms")) * time.Millisecond,
+
+			ProblemJSON:        viper.GetBool("http.server.problem_json"),
+			ProblemTypeBaseURI: viper.GetString("http.server.problem_type_base_uri"),
+
+			LogRequests: viper.GetBool("http.server.log_requests"),
+
+			TrustedProxies: trustedProxies,
 		},
 		Client: httpClient{
 			Timeout:             time.Duration(viper.GetInt("http.client.timeout_ms")) * time.Millisecond,
@@ -46,5 +91,38 @@ func loadHTTPConfig() HTTP {
 			MaxIdleConnsPerHost: viper.GetInt("http.client.max_idle_conns_per_host"),
 			IdleConnTimeout:     time.Duration(viper.GetInt("http.client.idle_conn_timeout")) * time.Second,
 		},
+		Redaction: httpRedaction{
+			DeniedHeaders:  viper.GetStringSlice("http.redaction.denied_headers"),
+			AllowedHeaders: viper.GetStringSlice("http.redaction.allowed_headers"),
+			ValueMasks:     valueMasks,
+		},
+	}
+}
+
+// parseValueMasks compiles the regular expressions masking the logged values.
+func parseValueMasks(patterns []string) (masks []*regexp.Regexp, err error) {
+	for _, pattern := range patterns {
+		var mask *regexp.Regexp
+		mask, err = regexp.Compile(pattern)
+		if err != nil {
+			err = errors.Wrapf(err, "invalid value mask '%s'", pattern)
+			return
+		}
+		masks = append(masks, mask)
+	}
+	return
+}
+
+// parseTrustedProxies parses the IP ranges of the trusted proxies in CIDR notation, e.g. "10.0.0.0/8".
+func parseTrustedProxies(cidrs []string) (ranges []*net.IPNet, err error) {
+	for _, cidr := range cidrs {
+		var ipRange *net.IPNet
+		_, ipRange, err = net.ParseCIDR(cidr)
+		if err != nil {
+			err = errors.Wrapf(err, "invalid trusted proxy range '%s'", cidr)
+			return
+		}
+		ranges = append(ranges, ipRange)
 	}
+	return
 }
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValueMasks(t *testing.T) {
+	masks, err := parseValueMasks([]string{`sk_live_[0-9a-z]+`, `pin:(\d+)`})
+	require.NoError(t, err)
+	require.Len(t, masks, 2)
+	require.True(t, masks[1].MatchString("pin:1234"))
+
+	masks, err = parseValueMasks(nil)
+	require.NoError(t, err)
+	require.Empty(t, masks)
+
+	_, err = parseValueMasks([]string{`sk_live_(`})
+	require.Error(t, err)
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	ranges, err := parseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::/32"})
+	require.NoError(t, err)
+	require.Len(t, ranges, 2)
+	require.Equal(t, "10.0.0.0/8", ranges[0].String())
+
+	ranges, err = parseTrustedProxies(nil)
+	require.NoError(t, err)
+	require.Empty(t, ranges)
+
+	_, err = parseTrustedProxies([]string{"10.0.0.1"})
+	require.Error(t, err)
+}
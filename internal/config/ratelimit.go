@@ -0,0 +1,103 @@
+package config
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// RateLimit configuration
+type RateLimit struct {
+	Enabled      bool
+	Rules        []RateLimitRule
+	APIKeyHeader string
+}
+
+// RateLimitRule is the configuration of a rate limit counted by client IP, API key or route.
+type RateLimitRule struct {
+	// Name identifies the counters of the rule, see http.RateLimitRule.
+	Name   string
+	By     string
+	Rate   int
+	Period time.Duration
+	Burst  int
+	Routes []string
+}
+
+func loadRateLimitConfig() RateLimit {
+	viper.SetDefault("ratelimit.enabled", false)
+	viper.SetDefault("ratelimit.api_key_header", "X-API-Key")
+
+	rules, err := loadRateLimitRules(viper.GetViper())
+	if err != nil {
+		logrus.Fatalf("Error loading ratelimit.rules: %+v", err)
+	}
+
+	return RateLimit{
+		Enabled:      viper.GetBool("ratelimit.enabled"),
+		Rules:        rules,
+		APIKeyHeader: viper.GetString("ratelimit.api_key_header"),
+	}
+}
+
+// rateLimitRuleConfig is the configuration of a rate limit rule, e.g.
+// {"ratelimit": {"rules": [{"name": "articles", "by": "route", "rate": 50, "period_ms": 1000, "burst": 10,
+// "routes": ["getArticle", "fetchArticles"]}]}}.
+type rateLimitRuleConfig struct {
+	Name     string   `mapstructure:"name"`
+	By       string   `mapstructure:"by"`
+	Rate     int      `mapstructure:"rate"`
+	PeriodMs int      `mapstructure:"period_ms"`
+	Burst    int      `mapstructure:"burst"`
+	Routes   []string `mapstructure:"routes"`
+}
+
+// loadRateLimitRules loads the rules of ratelimit.rules, with by one of ip, api_key or route, and unique names.
+func loadRateLimitRules(v *viper.Viper) (rules []RateLimitRule, err error) {
+	var confs []rateLimitRuleConfig
+	if err = v.UnmarshalKey("ratelimit.rules", &confs); err != nil {
+		err = errors.Wrap(err, "invalid rate limit rules")
+		return
+	}
+
+	names := make(map[string]bool, len(confs))
+	for i, conf := range confs {
+		switch conf.By {
+		case "ip", "api_key", "route":
+		default:
+			err = errors.Errorf("by of rate limit rule %d must be one of ip, api_key or route, got '%s'", i, conf.By)
+			return
+		}
+		if conf.Rate <= 0 {
+			err = errors.Errorf("rate of rate limit rule %d must be positive", i)
+			return
+		}
+		if conf.PeriodMs <= 0 {
+			err = errors.Errorf("period_ms of rate limit rule %d must be positive", i)
+			return
+		}
+		if conf.Burst < 0 {
+			err = errors.Errorf("burst of rate limit rule %d must not be negative", i)
+			return
+		}
+		if conf.Name != "" {
+			if names[conf.Name] {
+				err = errors.Errorf("name of rate limit rule %d is not unique, got '%s'", i, conf.Name)
+				return
+			}
+			names[conf.Name] = true
+		}
+
+		rules = append(rules, RateLimitRule{
+			Name:   conf.Name,
+			By:     conf.By,
+			Rate:   conf.Rate,
+			Period: time.Duration(conf.PeriodMs) * time.Millisecond,
+			Burst:  conf.Burst,
+			Routes: conf.Routes,
+		})
+	}
+	return
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRateLimitRules(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expected    []RateLimitRule
+		expectedErr bool
+	}{
+		"empty": {
+			input: `{}`,
+		},
+		"rules": {
+			input: `{"ratelimit": {"rules": [
+				{"by": "ip", "rate": 100, "period_ms": 60000, "burst": 20},
+				{"name": "articles", "by": "route", "rate": 50, "period_ms": 1000, "routes": ["getArticle", "fetchArticles"]}
+			]}}`,
+			expected: []RateLimitRule{
+				{By: "ip", Rate: 100, Period: time.Minute, Burst: 20},
+				{
+					Name:   "articles",
+					By:     "route",
+					Rate:   50,
+					Period: time.Second,
+					Routes: []string{"getArticle", "fetchArticles"},
+				},
+			},
+		},
+		"unknown by": {
+			input:       `{"ratelimit": {"rules": [{"by": "user", "rate": 100, "period_ms": 60000}]}}`,
+			expectedErr: true,
+		},
+		"missing period": {
+			input:       `{"ratelimit": {"rules": [{"by": "ip", "rate": 100}]}}`,
+			expectedErr: true,
+		},
+		"negative burst": {
+			input:       `{"ratelimit": {"rules": [{"by": "ip", "rate": 100, "period_ms": 60000, "burst": -1}]}}`,
+			expectedErr: true,
+		},
+		"duplicate name": {
+			input: `{"ratelimit": {"rules": [
+				{"name": "clients", "by": "ip", "rate": 100, "period_ms": 60000},
+				{"name": "clients", "by": "api_key", "rate": 100, "period_ms": 60000}
+			]}}`,
+			expectedErr: true,
+		},
+		"legacy string": {
+			input:       `{"ratelimit": {"rules": "ip=100/1m:20"}}`,
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res, err := loadRateLimitRules(newViper(t, test.input))
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
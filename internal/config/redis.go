@@ -1,17 +1,34 @@
 package config
 
 import (
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 // Redis configuration
 type Redis struct {
-	Address             string
-	Cluster             bool
-	DB                  int
+	// Address is the comma-separated addresses of the node, of the cluster nodes or of the sentinels.
+	Address string
+	Cluster bool
+	DB      int
+
+	// Username and Password authenticate with ACL, or with requirepass when Username is empty.
+	Username string
+	Password string
+
+	Sentinel RedisSentinel
+	TLS      RedisTLS
+
+	// ReadOnly, RouteByLatency and RouteRandomly route the read-only commands to the replicas in cluster mode, the last
+	// two also with Sentinel.RouteToReplicas.
+	ReadOnly       bool
+	RouteByLatency bool
+	RouteRandomly  bool
+
 	DialTimeout         time.Duration
 	ReadTimeout         time.Duration
 	WriteTimeout        time.Duration
@@ -21,17 +38,38 @@ type Redis struct {
 	ConnMaxLifetime     time.Duration
 	ShortExpirationTime time.Duration
 	LongExpirationTime  time.Duration
+
+	// CommandTimeout bounds the commands of the locks and of the rate limits, which would otherwise hold the requests
+	// up to the read timeout when redis is slow.
+	CommandTimeout time.Duration
+}
+
+// RedisSentinel is the configuration of the Sentinel failover mode, enabled when MasterName is set.
+type RedisSentinel struct {
+	MasterName string
+	Username   string
+	Password   string
+	// RouteToReplicas routes the read-only commands to the replicas, along with RouteByLatency or RouteRandomly.
+	RouteToReplicas bool
+}
+
+// RedisTLS is the configuration of TLS connections to redis.
+type RedisTLS struct {
+	Enabled bool
+	// CAFile is the PEM CA bundle verifying the server, the system pool being used when empty.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key, for mutual TLS.
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
 }
 
 func loadRedisConfig() Redis {
 	viper.SetDefault("redis.cluster", false)
 	viper.SetDefault("redis.db", 0)
-	viper.SetDefault("redis.dial_timeout_ms", 5000)
-	viper.SetDefault("redis.read_timeout_ms", 3000)
-	viper.SetDefault("redis.write_timeout_ms", 3000)
 	viper.SetDefault("redis.pool_size", 10)
-	viper.SetDefault("redis.pool_timeout", 4)
-	viper.SetDefault("redis.conn_max_idle_time", 300)
+	viper.SetDefault("redis.tls.enabled", false)
 
 	addr := viper.GetString("redis.address")
 	if addr == "" {
@@ -39,34 +77,117 @@ func loadRedisConfig() Redis {
 	}
 
 	conf := Redis{
-		Address:      addr,
-		Cluster:      viper.GetBool("redis.cluster"),
-		DB:           viper.GetInt("redis.db"),
-		DialTimeout:  time.Duration(viper.GetInt("redis.dial_timeout_ms")) * time.Millisecond,
-		ReadTimeout:  time.Duration(viper.GetInt("redis.read_timeout_ms")) * time.Millisecond,
-		WriteTimeout: time.Duration(viper.GetInt("redis.write_timeout_ms")) * time.Millisecond,
-		PoolSize:     viper.GetInt("redis.pool_size"),
-		PoolTimeout:  time.Duration(viper.GetInt("redis.pool_timeout")) * time.Millisecond,
+		Address:  addr,
+		Cluster:  viper.GetBool("redis.cluster"),
+		DB:       viper.GetInt("redis.db"),
+		Username: viper.GetString("redis.username"),
+		Password: viper.GetString("redis.password"),
+		Sentinel: RedisSentinel{
+			MasterName: viper.GetString("redis.sentinel.master_name"),
+			Username:   viper.GetString("redis.sentinel.username"),
+			Password:   viper.GetString("redis.sentinel.password"),
+
+			RouteToReplicas: viper.GetBool("redis.sentinel.route_to_replicas"),
+		},
+		TLS: RedisTLS{
+			Enabled:            viper.GetBool("redis.tls.enabled"),
+			CAFile:             viper.GetString("redis.tls.ca_file"),
+			CertFile:           viper.GetString("redis.tls.cert_file"),
+			KeyFile:            viper.GetString("redis.tls.key_file"),
+			ServerName:         viper.GetString("redis.tls.server_name"),
+			InsecureSkipVerify: viper.GetBool("redis.tls.insecure_skip_verify"),
+		},
+		ReadOnly:       viper.GetBool("redis.read_only"),
+		RouteByLatency: viper.GetBool("redis.route_by_latency"),
+		RouteRandomly:  viper.GetBool("redis.route_randomly"),
+		PoolSize:       viper.GetInt("redis.pool_size"),
 	}
 
-	connMaxIdleTime := viper.GetInt("redis.conn_max_idle_time")
-	if connMaxIdleTime > 0 {
-		conf.ConnMaxIdleTime = time.Duration(connMaxIdleTime) * time.Second
+	durations := []struct {
+		dst          *time.Duration
+		key          string
+		legacyKey    string
+		legacyUnit   time.Duration
+		defaultValue time.Duration
+	}{
+		{&conf.DialTimeout, "redis.dial_timeout_ms", "", 0, 5 * time.Second},
+		{&conf.ReadTimeout, "redis.read_timeout_ms", "", 0, 3 * time.Second},
+		{&conf.WriteTimeout, "redis.write_timeout_ms", "", 0, 3 * time.Second},
+		{&conf.CommandTimeout, "redis.command_timeout_ms", "", 0, 500 * time.Millisecond},
+		// redis.pool_timeout defaulted to 4, yet was read in milliseconds, which is kept rather than guessing
+		{&conf.PoolTimeout, "redis.pool_timeout_ms", "redis.pool_timeout", time.Millisecond, 4 * time.Second},
+		{&conf.ConnMaxIdleTime, "redis.conn_max_idle_time_ms", "redis.conn_max_idle_time", time.Second, 5 * time.Minute},
+		{&conf.ConnMaxLifetime, "redis.conn_max_lifetime_ms", "redis.conn_max_lifetime", time.Second, 0},
+		{&conf.ShortExpirationTime, "redis.short_expiration_time_ms", "redis.short_expiration_time", time.Second, 0},
+		{&conf.LongExpirationTime, "redis.long_expiration_time_ms", "redis.long_expiration_time", time.Second, 0},
+	}
+	for _, d := range durations {
+		var err error
+		*d.dst, err = getDurationMs(d.key, d.legacyKey, d.legacyUnit, d.defaultValue)
+		if err != nil {
+			logrus.Fatalf("Error loading redis config: %+v", err)
+		}
 	}
 
-	connMaxLifetime := viper.GetInt("redis.conn_max_lifetime")
-	if connMaxLifetime > 0 {
-		conf.ConnMaxLifetime = time.Duration(connMaxLifetime) * time.Second
+	if err := conf.validate(); err != nil {
+		logrus.Fatalf("Invalid redis config: %+v", err)
 	}
 
-	shortExpirationTime := viper.GetInt("redis.short_expiration_time")
-	if shortExpirationTime > 0 {
-		conf.ShortExpirationTime = time.Duration(shortExpirationTime) * time.Second
+	return conf
+}
+
+// getDurationMs returns the duration of key in milliseconds. When key is not set, the deprecated legacyKey is read
+// in legacyUnit, the unit it was read in so far.
+func getDurationMs(
+	key string,
+	legacyKey string,
+	legacyUnit time.Duration,
+	defaultValue time.Duration,
+) (d time.Duration, err error) {
+	switch {
+	case viper.IsSet(key):
+		d = time.Duration(viper.GetInt64(key)) * time.Millisecond
+	case legacyKey != "" && viper.IsSet(legacyKey):
+		d = time.Duration(viper.GetInt64(legacyKey)) * legacyUnit
+		logrus.Warnf("%s is deprecated, use %s instead: read as %s in units of %s", legacyKey, key, d, legacyUnit)
+		key = legacyKey
+	default:
+		d = defaultValue
 	}
-	longExpirationTime := viper.GetInt("redis.long_expiration_time")
-	if longExpirationTime > 0 {
-		conf.LongExpirationTime = time.Duration(longExpirationTime) * time.Second
+
+	if d < 0 {
+		err = errors.Errorf("%s must not be negative, got %s", key, d)
 	}
+	return
+}
 
-	return conf
+func (c Redis) validate() error {
+	if c.Cluster && c.Sentinel.MasterName != "" {
+		return errors.New("redis.cluster and redis.sentinel.master_name are exclusive, " +
+			"see redis.sentinel.route_to_replicas to read from the replicas")
+	}
+	if c.Cluster && c.DB != 0 {
+		return errors.New("redis.db is not supported in cluster mode")
+	}
+	if c.ReadOnly && !c.Cluster {
+		return errors.New("redis.read_only requires redis.cluster")
+	}
+	if (c.RouteByLatency || c.RouteRandomly) && !c.Cluster && !c.Sentinel.RouteToReplicas {
+		return errors.New("redis.route_by_latency and redis.route_randomly require redis.cluster " +
+			"or redis.sentinel.route_to_replicas")
+	}
+	if c.Sentinel.MasterName == "" &&
+		(c.Sentinel.Username != "" || c.Sentinel.Password != "" || c.Sentinel.RouteToReplicas) {
+		return errors.New("redis.sentinel.master_name is required with the other redis.sentinel options")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return errors.New("redis.tls.cert_file and redis.tls.key_file must be set together")
+	}
+	if !c.TLS.Enabled && (c.TLS.CAFile != "" || c.TLS.CertFile != "" || c.TLS.InsecureSkipVerify) {
+		return errors.New("redis.tls.enabled must be set to use the other redis.tls options")
+	}
+	if len(strings.Split(c.Address, ",")) > 1 && !c.Cluster && c.Sentinel.MasterName == "" {
+		return errors.New("multiple redis.address require redis.cluster or redis.sentinel.master_name")
+	}
+	return nil
 }
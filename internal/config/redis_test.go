@@ -0,0 +1,122 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDurationMs(t *testing.T) {
+	tests := map[string]struct {
+		values      map[string]interface{}
+		expected    time.Duration
+		expectedErr bool
+	}{
+		"default": {
+			expected: 4 * time.Second,
+		},
+		"milliseconds": {
+			values:   map[string]interface{}{"redis.pool_timeout_ms": 1500},
+			expected: 1500 * time.Millisecond,
+		},
+		"legacy milliseconds": {
+			values:   map[string]interface{}{"redis.pool_timeout": 2},
+			expected: 2 * time.Millisecond,
+		},
+		"milliseconds over legacy": {
+			values:   map[string]interface{}{"redis.pool_timeout_ms": 1500, "redis.pool_timeout": 2},
+			expected: 1500 * time.Millisecond,
+		},
+		"negative": {
+			values:      map[string]interface{}{"redis.pool_timeout_ms": -1},
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			for key, value := range test.values {
+				viper.Set(key, value)
+			}
+
+			res, err := getDurationMs("redis.pool_timeout_ms", "redis.pool_timeout", time.Millisecond, 4*time.Second)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestRedisValidate(t *testing.T) {
+	tests := map[string]struct {
+		conf        Redis
+		expectedErr bool
+	}{
+		"single node": {
+			conf: Redis{Address: "localhost:6379", DB: 2},
+		},
+		"cluster with replica reads": {
+			conf: Redis{Address: "node1:6379,node2:6379", Cluster: true, RouteByLatency: true},
+		},
+		"sentinel": {
+			conf: Redis{Address: "sentinel1:26379,sentinel2:26379", Sentinel: RedisSentinel{MasterName: "mymaster"}},
+		},
+		"mutual tls": {
+			conf: Redis{Address: "localhost:6379", TLS: RedisTLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem"}},
+		},
+		"sentinel with replica reads": {
+			conf: Redis{
+				Address:        "sentinel1:26379",
+				DB:             1,
+				RouteByLatency: true,
+				Sentinel:       RedisSentinel{MasterName: "mymaster", RouteToReplicas: true},
+			},
+		},
+		"sentinel in cluster": {
+			conf:        Redis{Address: "sentinel1:26379", Cluster: true, Sentinel: RedisSentinel{MasterName: "mymaster"}},
+			expectedErr: true,
+		},
+		"sentinel replica reads without master": {
+			conf:        Redis{Address: "localhost:6379", Sentinel: RedisSentinel{RouteToReplicas: true}},
+			expectedErr: true,
+		},
+		"db in cluster": {
+			conf:        Redis{Address: "node1:6379", Cluster: true, DB: 1},
+			expectedErr: true,
+		},
+		"replica reads without cluster": {
+			conf:        Redis{Address: "localhost:6379", ReadOnly: true},
+			expectedErr: true,
+		},
+		"multiple addresses on single node": {
+			conf:        Redis{Address: "node1:6379,node2:6379"},
+			expectedErr: true,
+		},
+		"client cert without key": {
+			conf:        Redis{Address: "localhost:6379", TLS: RedisTLS{Enabled: true, CertFile: "cert.pem"}},
+			expectedErr: true,
+		},
+		"tls options without tls": {
+			conf:        Redis{Address: "localhost:6379", TLS: RedisTLS{CAFile: "ca.pem"}},
+			expectedErr: true,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := test.conf.validate()
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
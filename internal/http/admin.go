@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+)
+
+const defaultAdminKeysLimit = 100
+
+type adminKey struct {
+	Key        string  `json:"key"`
+	StorageKey string  `json:"storage_key"`
+	TTLMs      int64   `json:"ttl_ms"`
+	Value      *string `json:"value,omitempty"`
+	Negative   bool    `json:"negative,omitempty"`
+}
+
+func newAdminKey(key goboilerplate.CacheKey) adminKey {
+	return adminKey{
+		Key:        key.Key,
+		StorageKey: key.StorageKey,
+		TTLMs:      key.TTL.Milliseconds(),
+	}
+}
+
+type adminUsage struct {
+	Group string `json:"group"`
+	Keys  int64  `json:"keys"`
+	Bytes int64  `json:"bytes"`
+}
+
+// AddCacheAdminHandler adds the routes administrating the cache to g. The keys are given as query parameter, since
+// they may contain '/'.
+func AddCacheAdminHandler(g *echo.Group, admin *cache.Admin) {
+	g.GET("/cache/keys", func(c echo.Context) error {
+		limit := defaultAdminKeysLimit
+		if limitStr := c.QueryParam("limit"); limitStr != "" {
+			var err error
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 {
+				return goboilerplate.ConstraintErrorf("invalid limit '%s'", limitStr)
+			}
+		}
+
+		keys, err := admin.Keys(c.Request().Context(), c.QueryParam("pattern"), limit)
+		if err != nil {
+			return err
+		}
+
+		res := make([]adminKey, len(keys))
+		for i, key := range keys {
+			res[i] = newAdminKey(key)
+		}
+		return c.JSON(http.StatusOK, res)
+	}).Name = "adminCacheKeys"
+
+	g.GET("/cache/key", func(c echo.Context) error {
+		key := c.QueryParam("key")
+		if key == "" {
+			return goboilerplate.ConstraintErrorf("key is required")
+		}
+
+		info, err := admin.Get(c.Request().Context(), key)
+		if err != nil {
+			return err
+		}
+
+		res := newAdminKey(info.CacheKey)
+		if !info.Negative {
+			res.Value = &info.Value
+		}
+		res.Negative = info.Negative
+		return c.JSON(http.StatusOK, res)
+	}).Name = "adminCacheGetKey"
+
+	g.DELETE("/cache/key", func(c echo.Context) error {
+		key := c.QueryParam("key")
+		if key == "" {
+			return goboilerplate.ConstraintErrorf("key is required")
+		}
+
+		if err := admin.Del(c.Request().Context(), key); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}).Name = "adminCacheDelKey"
+
+	g.POST("/cache/namespaces/:namespace/bump", func(c echo.Context) error {
+		namespace := c.Param("namespace")
+
+		version, err := admin.BumpNamespace(c.Request().Context(), namespace)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"namespace": namespace, "version": version})
+	}).Name = "adminCacheBumpNamespace"
+
+	g.GET("/cache/usage", func(c echo.Context) error {
+		usage, err := admin.MemoryUsage(c.Request().Context())
+		if err != nil {
+			return err
+		}
+
+		res := make([]adminUsage, len(usage))
+		for i, group := range usage {
+			res[i] = adminUsage(group)
+		}
+		return c.JSON(http.StatusOK, res)
+	}).Name = "adminCacheUsage"
+}
@@ -0,0 +1,136 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	_redis "github.com/go-redis/redis/v9"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	handler "github.com/kurio/boilerplate-go/internal/http"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+func TestCacheAdminHandler(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	redisClient := _redis.NewClient(&_redis.Options{Addr: server.Addr()})
+	defer redisClient.Close()
+
+	storage := redis.NewNamespacedRedisCacher(redisClient, goboilerplate.ExpiryConf{}, "test", time.Second)
+	cacher := cache.NewNegativeCacher(storage)
+
+	require.NoError(t, cacher.Set(ctx, "article:1", "one", goboilerplate.DurationShort))
+	require.NoError(t, cacher.Set(ctx, "article:2", "two", goboilerplate.DurationLong))
+	require.NoError(t, cacher.SetNotFound(ctx, "article:3"))
+	require.NoError(t, cacher.Set(ctx, "user:1", "someone", goboilerplate.DurationShort))
+	refreshingLoader := cache.NewRefreshingLoader(cacher, nil, 0, goboilerplate.ExpiryConf{
+		goboilerplate.DurationShort: {TTL: 30 * time.Second},
+	}, 1)
+	require.NoError(t, refreshingLoader.Set(ctx, "video:1", "refreshed", goboilerplate.DurationShort))
+
+	e := echo.New()
+	e.HTTPErrorHandler = handler.ErrorHandler
+	admin := cache.NewAdmin(cacher, storage.(cache.AdminStorage))
+	handler.AddCacheAdminHandler(e.Group("/admin", handler.BearerTokenMiddleware("secret")), admin)
+
+	do := func(method string, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/cache/keys", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rec := do(http.MethodGet, "/admin/cache/key?key=article:1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{
+			"key": "article:1",
+			"storage_key": "test###ns###article###v0###article:1",
+			"ttl_ms": 60000,
+			"value": "one"
+		}`, rec.Body.String())
+
+		rec = do(http.MethodGet, "/admin/cache/key?key=article:3")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{
+			"key": "article:3",
+			"storage_key": "test###ns###article###v0###article:3",
+			"ttl_ms": 10000,
+			"negative": true
+		}`, rec.Body.String())
+
+		rec = do(http.MethodGet, "/admin/cache/key?key=article:4")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+
+		// without the header of the refreshing loader
+		rec = do(http.MethodGet, "/admin/cache/key?key=video:1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{
+			"key": "video:1",
+			"storage_key": "test###ns###video###v0###video:1",
+			"ttl_ms": 60000,
+			"value": "refreshed"
+		}`, rec.Body.String())
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		rec := do(http.MethodGet, "/admin/cache/keys?pattern=article:*")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `[
+			{"key":"article:1","storage_key":"test###ns###article###v0###article:1","ttl_ms":60000},
+			{"key":"article:2","storage_key":"test###ns###article###v0###article:2","ttl_ms":3600000},
+			{"key":"article:3","storage_key":"test###ns###article###v0###article:3","ttl_ms":10000}
+		]`, rec.Body.String())
+
+		rec = do(http.MethodGet, "/admin/cache/keys?pattern=*:1&limit=1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var keys []map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &keys))
+		require.Len(t, keys, 1)
+		require.Contains(t, []interface{}{"article:1", "user:1", "video:1"}, keys[0]["key"])
+
+		rec = do(http.MethodGet, "/admin/cache/keys?limit=0")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("del", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/admin/cache/key?key=user:1")
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = do(http.MethodDelete, "/admin/cache/key?key=user:1")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("bump", func(t *testing.T) {
+		rec := do(http.MethodPost, "/admin/cache/namespaces/article/bump")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"namespace":"article","version":1}`, rec.Body.String())
+
+		// the keys of the previous version are left to expire, out of sight
+		rec = do(http.MethodGet, "/admin/cache/keys?pattern=article:*")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `[]`, rec.Body.String())
+
+		rec = do(http.MethodGet, "/admin/cache/key?key=article:1")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -1,84 +1,245 @@
 package http
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	goboilerplate "github.com/kurio/boilerplate-go"
 	"github.com/labstack/echo/v4"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// ErrorHandler always return JSON, even on debug mode
-func ErrorHandler(err error, c echo.Context) {
-	if err == nil {
+const instrumentationName = "github.com/kurio/boilerplate-go/internal/http"
+
+// errorKind is how the errors of a kind are reported.
+type errorKind struct {
+	status int
+	level  logrus.Level
+}
+
+// errorKinds maps every kind of error to its HTTP status and log severity, its code being the metric label.
+var errorKinds = map[goboilerplate.ErrorCode]errorKind{
+	goboilerplate.CodeInternal:              {status: http.StatusInternalServerError, level: logrus.ErrorLevel},
+	goboilerplate.CodeBadRequest:            {status: http.StatusBadRequest, level: logrus.DebugLevel},
+	goboilerplate.CodeUnauthorized:          {status: http.StatusUnauthorized, level: logrus.DebugLevel},
+	goboilerplate.CodeForbidden:             {status: http.StatusForbidden, level: logrus.DebugLevel},
+	goboilerplate.CodeNotFound:              {status: http.StatusNotFound, level: logrus.DebugLevel},
+	goboilerplate.CodeTimeout:               {status: http.StatusRequestTimeout, level: logrus.DebugLevel},
+	goboilerplate.CodeConflict:              {status: http.StatusConflict, level: logrus.DebugLevel},
+	goboilerplate.CodeGone:                  {status: http.StatusGone, level: logrus.DebugLevel},
+	goboilerplate.CodePreconditionFailed:    {status: http.StatusPreconditionFailed, level: logrus.DebugLevel},
+	goboilerplate.CodeTooManyRequests:       {status: http.StatusTooManyRequests, level: logrus.DebugLevel},
+	goboilerplate.CodeDependencyUnavailable: {status: http.StatusServiceUnavailable, level: logrus.WarnLevel},
+}
+
+// statusErrorCode returns the kind of the errors with an HTTP status, such as echo.HTTPError.
+func statusErrorCode(status int) goboilerplate.ErrorCode {
+	for code, kind := range errorKinds {
+		if kind.status == status {
+			return code
+		}
+	}
+	if status < http.StatusInternalServerError {
+		return goboilerplate.CodeBadRequest
+	}
+	return goboilerplate.CodeInternal
+}
+
+func newHTTPErrorsCounter(meterProvider metric.MeterProvider) syncint64.Counter {
+	counter, err := meterProvider.Meter(instrumentationName).SyncInt64().Counter(
+		"http.server.errors",
+		instrument.WithDescription("The number of requests failed with an error, by error code and HTTP status."),
+	)
+	if err != nil {
+		logrus.Errorf("Error creating http.server.errors counter: %+v", err)
+	}
+	return counter
+}
+
+// ErrorHandlerConfig is the configuration of NewErrorHandler.
+type ErrorHandlerConfig struct {
+	// ProblemJSON replies application/problem+json bodies even when the client does not accept them.
+	ProblemJSON bool
+	// ProblemTypeBaseURI is joined with the error code to build the type of the problems, "about:blank" when empty.
+	ProblemTypeBaseURI string
+	// Redaction masks the secrets of the logged headers, URI and error, DefaultRedactionPolicy when nil.
+	Redaction *RedactionPolicy
+	// MeterProvider provides the http.server.errors metric, the global one when nil.
+	MeterProvider metric.MeterProvider
+}
+
+// problem is an RFC 7807 problem details object, extended with the error code and the trace ID.
+type problem struct {
+	Type     string                  `json:"type"`
+	Title    string                  `json:"title"`
+	Status   int                     `json:"status"`
+	Detail   string                  `json:"detail,omitempty"`
+	Instance string                  `json:"instance,omitempty"`
+	Code     goboilerplate.ErrorCode `json:"code"`
+	TraceID  string                  `json:"trace_id,omitempty"`
+	// Violations are the invalid fields of a goboilerplate.ValidationError.
+	Violations []violation `json:"violations,omitempty"`
+}
+
+type violation struct {
+	Field   string   `json:"field"`
+	Rule    string   `json:"rule"`
+	Params  []string `json:"params,omitempty"`
+	Message string   `json:"message"`
+}
+
+func newViolations(err error) (violations []violation) {
+	var validationErr *goboilerplate.ValidationError
+	if !errors.As(err, &validationErr) {
 		return
 	}
 
-	originalError := errors.Cause(err)
+	violations = make([]violation, len(validationErr.Violations))
+	for i, v := range validationErr.Violations {
+		violations[i] = violation(v)
+	}
+	return
+}
+
+var defaultErrorHandler = NewErrorHandler(ErrorHandlerConfig{})
+
+// ErrorHandler is the error handler with the default configuration, see NewErrorHandler.
+func ErrorHandler(err error, c echo.Context) {
+	defaultErrorHandler(err, c)
+}
 
-	headers := []string{}
-	for k, v := range c.Request().Header {
-		headers = append(headers, fmt.Sprintf(`%s:%s`, k, strings.Join(v, ",")))
+// NewErrorHandler returns an error handler always replying JSON, even on debug mode.
+// The response holds the code of the kind of error and its message, see goboilerplate.ClassifyError,
+// as {"code": ..., "message": ...} or as an RFC 7807 application/problem+json body when the client
+// accepts it or conf.ProblemJSON is set. The violations of a goboilerplate.ValidationError are listed
+// in both. The errors are logged with the severity of their kind, redacted by conf.Redaction, and
+// counted in the http.server.errors metric.
+func NewErrorHandler(conf ErrorHandlerConfig) echo.HTTPErrorHandler {
+	redaction := DefaultRedactionPolicy()
+	if conf.Redaction != nil {
+		redaction = *conf.Redaction
 	}
+	if conf.MeterProvider == nil {
+		conf.MeterProvider = global.MeterProvider()
+	}
+	httpErrors := newHTTPErrorsCounter(conf.MeterProvider)
 
-	log := logrus.WithFields(logrus.Fields{
-		"headers": strings.Join(headers, " | "),
-		"method":  c.Request().Method,
-		"uri":     c.Request().RequestURI,
-	})
-
-	code := http.StatusInternalServerError
-	message := originalError.Error()
-	logError := true
-
-	switch e := originalError.(type) {
-	case goboilerplate.ConstraintError:
-		code = http.StatusBadRequest
-		message = e.Error()
-		logError = false
-	case *echo.HTTPError:
-		if e.Internal != nil {
-			if herr, ok := e.Internal.(*echo.HTTPError); ok {
-				e = herr
-			}
+	return func(err error, c echo.Context) {
+		if err == nil {
+			return
 		}
 
-		code = e.Code
-		if m, ok := e.Message.(string); ok {
-			message = m
-		} else {
-			message = e.Error()
+		fields := logrus.Fields{
+			"headers": redaction.Headers(c.Request().Header),
+			"method":  c.Request().Method,
+			"uri":     redaction.Value(c.Request().RequestURI),
 		}
+		var traceID string
+		if spanContext := trace.SpanContextFromContext(c.Request().Context()); spanContext.HasTraceID() {
+			traceID = spanContext.TraceID().String()
+			fields["trace_id"] = traceID
+		}
+		log := logrus.WithFields(fields)
 
-		logError = code >= 500
-	}
+		code, cause := goboilerplate.ClassifyError(err)
+		status := errorKinds[code].status
+		message := cause.Error()
 
-	switch originalError {
-	case context.DeadlineExceeded, context.Canceled:
-		code = http.StatusRequestTimeout
-		logError = false
-	case goboilerplate.ErrNotFound:
-		code = http.StatusNotFound
-		logError = false
-	}
+		var e *echo.HTTPError
+		if errors.As(err, &e) {
+			if e.Internal != nil {
+				if herr, ok := e.Internal.(*echo.HTTPError); ok {
+					e = herr
+				}
+			}
 
-	if logError {
-		log.Errorf("%+v", err)
-	}
+			status = e.Code
+			code = statusErrorCode(status)
+			if m, ok := e.Message.(string); ok {
+				message = m
+			} else {
+				message = e.Error()
+			}
+		}
+
+		log.Log(errorKinds[code].level, redaction.Value(fmt.Sprintf("%+v", err)))
+		if httpErrors != nil {
+			httpErrors.Add(c.Request().Context(), 1,
+				attribute.String("error.code", string(code)),
+				attribute.Int("http.status_code", status),
+			)
+		}
 
-	// Send response
-	if !c.Response().Committed {
-		if c.Request().Method == http.MethodHead {
-			err = c.NoContent(code)
-		} else {
-			err = c.JSON(code, map[string]interface{}{"message": message})
+		// Send response
+		if c.Response().Committed {
+			return
+		}
+
+		switch {
+		case c.Request().Method == http.MethodHead:
+			err = c.NoContent(status)
+		case conf.ProblemJSON || acceptsProblemJSON(c.Request()):
+			problemType := "about:blank"
+			if conf.ProblemTypeBaseURI != "" {
+				problemType = strings.TrimSuffix(conf.ProblemTypeBaseURI, "/") + "/" + string(code)
+			}
+
+			var body []byte
+			body, err = json.Marshal(problem{
+				Type:       problemType,
+				Title:      http.StatusText(status),
+				Status:     status,
+				Detail:     message,
+				Instance:   c.Request().URL.Path,
+				Code:       code,
+				TraceID:    traceID,
+				Violations: newViolations(err),
+			})
+			if err == nil {
+				err = c.Blob(status, mimeApplicationProblemJSON, body)
+			}
+		default:
+			body := map[string]interface{}{"code": code, "message": message}
+			if violations := newViolations(err); len(violations) > 0 {
+				body["violations"] = violations
+			}
+			err = c.JSON(status, body)
 		}
 		if err != nil {
 			c.Echo().Logger.Error(err)
 		}
 	}
 }
+
+const mimeApplicationProblemJSON = "application/problem+json"
+
+// acceptsProblemJSON tells whether the Accept header of req lists application/problem+json.
+func acceptsProblemJSON(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get(echo.HeaderAccept), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(accept), ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), mimeApplicationProblemJSON) {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q <= 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -16,9 +19,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func getErrorResponse(t *testing.T, err error) string {
+func getErrorResponse(t *testing.T, code goboilerplate.ErrorCode, err error) string {
 	t.Helper()
 
 	if err == nil {
@@ -26,6 +32,7 @@ func getErrorResponse(t *testing.T, err error) string {
 	}
 
 	b, err := json.Marshal(map[string]interface{}{
+		"code":    code,
 		"message": err.Error(),
 	})
 	require.NoError(t, err)
@@ -71,8 +78,12 @@ func TestErrorHandling(t *testing.T) {
 			handler: func(c echo.Context) error {
 				return goboilerplate.ConstraintErrorf("invalid int: %s", "a")
 			},
-			expectedStatus:      http.StatusBadRequest,
-			expectedBody:        getErrorResponse(t, goboilerplate.ConstraintErrorf("invalid int: %s", "a")),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeBadRequest,
+				goboilerplate.ConstraintErrorf("invalid int: %s", "a"),
+			),
 			expectedLogIncludes: []string{},
 		},
 		"not found": {
@@ -80,7 +91,7 @@ func TestErrorHandling(t *testing.T) {
 				return goboilerplate.ErrNotFound
 			},
 			expectedStatus:      http.StatusNotFound,
-			expectedBody:        getErrorResponse(t, goboilerplate.ErrNotFound),
+			expectedBody:        getErrorResponse(t, goboilerplate.CodeNotFound, goboilerplate.ErrNotFound),
 			expectedLogIncludes: []string{},
 		},
 		"wrapped errors": {
@@ -92,7 +103,7 @@ func TestErrorHandling(t *testing.T) {
 				return err
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   getErrorResponse(t, errors.New("unexpected error")),
+			expectedBody:   getErrorResponse(t, goboilerplate.CodeInternal, errors.New("unexpected error")),
 			expectedLogIncludes: []string{
 				"unexpected error",
 				"first layer",
@@ -100,12 +111,104 @@ func TestErrorHandling(t *testing.T) {
 				"third layer",
 			},
 		},
+		"unauthorized": {
+			handler: func(c echo.Context) error {
+				return goboilerplate.UnauthorizedErrorf("invalid token")
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeUnauthorized,
+				goboilerplate.UnauthorizedErrorf("invalid token"),
+			),
+			expectedLogIncludes: []string{},
+		},
+		"wrapped forbidden": {
+			handler: func(c echo.Context) error {
+				return errors.Wrap(goboilerplate.ForbiddenErrorf("not the author"), "error updating article")
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeForbidden,
+				goboilerplate.ForbiddenErrorf("not the author"),
+			),
+			expectedLogIncludes: []string{},
+		},
+		"conflict": {
+			handler: func(c echo.Context) error {
+				return goboilerplate.ConflictErrorf("duplicate slug")
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeConflict,
+				goboilerplate.ConflictErrorf("duplicate slug"),
+			),
+			expectedLogIncludes: []string{},
+		},
+		"gone": {
+			handler: func(c echo.Context) error {
+				return goboilerplate.GoneErrorf("article removed")
+			},
+			expectedStatus: http.StatusGone,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeGone,
+				goboilerplate.GoneErrorf("article removed"),
+			),
+			expectedLogIncludes: []string{},
+		},
+		"precondition failed": {
+			handler: func(c echo.Context) error {
+				return goboilerplate.PreconditionFailedErrorf("stale version")
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodePreconditionFailed,
+				goboilerplate.PreconditionFailedErrorf("stale version"),
+			),
+			expectedLogIncludes: []string{},
+		},
+		"too many requests": {
+			handler: func(c echo.Context) error {
+				return goboilerplate.TooManyRequestsErrorf("slow down")
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeTooManyRequests,
+				goboilerplate.TooManyRequestsErrorf("slow down"),
+			),
+			expectedLogIncludes: []string{},
+		},
+		"dependency unavailable": {
+			handler: func(c echo.Context) error {
+				return errors.Wrap(goboilerplate.DependencyUnavailableErrorf("mysql is down"), "error fetching articles")
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: getErrorResponse(
+				t,
+				goboilerplate.CodeDependencyUnavailable,
+				goboilerplate.DependencyUnavailableErrorf("mysql is down"),
+			),
+			expectedLogIncludes: []string{"level=warning", "mysql is down", "error fetching articles"},
+		},
+		"handler returns HTTPError with a known status": {
+			handler: func(c echo.Context) error {
+				return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+			},
+			expectedStatus:      http.StatusForbidden,
+			expectedBody:        `{"code":"forbidden","message":"forbidden"}`,
+			expectedLogIncludes: []string{},
+		},
 		"handler returns HTTPError": {
 			handler: func(c echo.Context) error {
 				return echo.NewHTTPError(http.StatusBadGateway, "bad gateway error")
 			},
 			expectedStatus:      http.StatusBadGateway,
-			expectedBody:        `{"message":"bad gateway error"}`,
+			expectedBody:        `{"code":"internal","message":"bad gateway error"}`,
 			expectedLogIncludes: []string{"bad gateway error"},
 		},
 	}
@@ -158,3 +261,255 @@ func TestErrorHandling_HEAD(t *testing.T) {
 	require.Empty(t, rec.Body)
 	require.Contains(t, buf.String(), "unexpected error")
 }
+
+func TestErrorHandling_Metric(t *testing.T) {
+	ctx := context.Background()
+
+	reader := sdkmetric.NewManualReader()
+
+	e := echo.New()
+	e.HTTPErrorHandler = handler.NewErrorHandler(handler.ErrorHandlerConfig{
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	e.GET("/forbidden", func(c echo.Context) error {
+		return goboilerplate.ForbiddenErrorf("forbidden")
+	})
+	e.GET("/internal", func(c echo.Context) error {
+		return errors.New("unexpected error")
+	})
+
+	out := logrus.StandardLogger().Out
+	t.Cleanup(func() {
+		logrus.SetOutput(out)
+	})
+	logrus.SetOutput(io.Discard)
+	for _, path := range []string{"/forbidden", "/forbidden", "/internal"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(echo.GET, path, nil))
+	}
+
+	rm, err := reader.Collect(ctx)
+	require.NoError(t, err)
+
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "http.server.errors" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				code, _ := dp.Attributes.Value("error.code")
+				status, _ := dp.Attributes.Value("http.status_code")
+				counts[fmt.Sprintf("%s %d", code.AsString(), status.AsInt64())] = dp.Value
+			}
+		}
+	}
+	require.Equal(t, map[string]int64{"forbidden 403": 2, "internal 500": 1}, counts)
+}
+
+func TestErrorHandling_ProblemJSON(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("0102030405060708")
+	require.NoError(t, err)
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+
+	tests := map[string]struct {
+		conf         handler.ErrorHandlerConfig
+		accept       string
+		traced       bool
+		expectedType string
+		expectedBody string
+	}{
+		"accepted": {
+			accept:       "application/json, application/problem+json",
+			traced:       true,
+			expectedType: "application/problem+json",
+			expectedBody: `{
+				"type": "about:blank",
+				"title": "Forbidden",
+				"status": 403,
+				"detail": "not the author",
+				"instance": "/articles/1",
+				"code": "forbidden",
+				"trace_id": "0102030405060708090a0b0c0d0e0f10"
+			}`,
+		},
+		"configured": {
+			conf:         handler.ErrorHandlerConfig{ProblemJSON: true, ProblemTypeBaseURI: "https://errors.example.com/"},
+			expectedType: "application/problem+json",
+			expectedBody: `{
+				"type": "https://errors.example.com/forbidden",
+				"title": "Forbidden",
+				"status": 403,
+				"detail": "not the author",
+				"instance": "/articles/1",
+				"code": "forbidden"
+			}`,
+		},
+		"refused": {
+			accept:       "application/problem+json;q=0, application/json",
+			expectedType: echo.MIMEApplicationJSONCharsetUTF8,
+			expectedBody: `{"code":"forbidden","message":"not the author"}`,
+		},
+		"not accepted": {
+			accept:       "application/json",
+			expectedType: echo.MIMEApplicationJSONCharsetUTF8,
+			expectedBody: `{"code":"forbidden","message":"not the author"}`,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = handler.NewErrorHandler(test.conf)
+			e.GET("/articles/:id", func(c echo.Context) error {
+				return errors.Wrap(goboilerplate.ForbiddenErrorf("not the author"), "error updating article")
+			})
+
+			req := httptest.NewRequest(echo.GET, "/articles/1?draft=true", nil)
+			req.Header.Set(echo.HeaderAccept, test.accept)
+			if test.traced {
+				req = req.WithContext(trace.ContextWithSpanContext(req.Context(), spanContext))
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusForbidden, rec.Code)
+			require.Equal(t, test.expectedType, rec.Header().Get(echo.HeaderContentType))
+			require.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+// opError is a custom wrapper.
+type opError struct {
+	op  string
+	err error
+}
+
+func (e *opError) Error() string {
+	return e.op + ": " + e.err.Error()
+}
+
+func (e *opError) Unwrap() error {
+	return e.err
+}
+
+// missingError is not ErrNotFound, but tells it is.
+type missingError struct{}
+
+func (missingError) Error() string {
+	return "missing"
+}
+
+func (missingError) Is(target error) bool {
+	return target == goboilerplate.ErrNotFound
+}
+
+func TestErrorHandling_Chains(t *testing.T) {
+	tests := map[string]struct {
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		"%w wrapped": {
+			err:            fmt.Errorf("error getting article: %w", goboilerplate.ErrNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":"not_found","message":"not found"}`,
+		},
+		"%w wrapped negative cached": {
+			err:            fmt.Errorf("error getting article: %w", goboilerplate.ErrNegativeCached),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":"not_found","message":"not found"}`,
+		},
+		"%w wrapped context error": {
+			err:            fmt.Errorf("error querying: %w", context.DeadlineExceeded),
+			expectedStatus: http.StatusRequestTimeout,
+			expectedBody:   `{"code":"timeout","message":"context deadline exceeded"}`,
+		},
+		"custom wrapper": {
+			err:            &opError{op: "update", err: goboilerplate.ForbiddenErrorf("not the author")},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"code":"forbidden","message":"not the author"}`,
+		},
+		"pkg/errors around %w around custom wrapper": {
+			err: errors.Wrap(
+				fmt.Errorf("second: %w", &opError{op: "first", err: goboilerplate.GoneErrorf("removed")}),
+				"third",
+			),
+			expectedStatus: http.StatusGone,
+			expectedBody:   `{"code":"gone","message":"removed"}`,
+		},
+		"custom Is": {
+			err:            fmt.Errorf("error getting article: %w", missingError{}),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":"not_found","message":"not found"}`,
+		},
+		"joined": {
+			err:            stderrors.Join(errors.New("error closing"), goboilerplate.ConflictErrorf("duplicate slug")),
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"code":"conflict","message":"duplicate slug"}`,
+		},
+		"joined, the coded error wins": {
+			err: stderrors.Join(
+				fmt.Errorf("first: %w", goboilerplate.PreconditionFailedErrorf("stale")),
+				goboilerplate.ErrNotFound,
+			),
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"code":"precondition_failed","message":"stale"}`,
+		},
+		"wrapped joined": {
+			err: fmt.Errorf("error saving: %w", stderrors.Join(
+				errors.New("error closing"),
+				&opError{op: "insert", err: goboilerplate.DependencyUnavailableErrorf("mysql is down")},
+			)),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"code":"dependency_unavailable","message":"mysql is down"}`,
+		},
+		"joined without kind": {
+			err:            stderrors.Join(errors.New("error one"), errors.New("error two")),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"code":"internal","message":"error one\nerror two"}`,
+		},
+		"%w wrapped without kind": {
+			err:            fmt.Errorf("second: %w", fmt.Errorf("first: %w", errors.New("unexpected error"))),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"code":"internal","message":"unexpected error"}`,
+		},
+		"%w wrapped HTTPError": {
+			err:            fmt.Errorf("error binding: %w", echo.NewHTTPError(http.StatusUnsupportedMediaType, "unsupported")),
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"code":"bad_request","message":"unsupported"}`,
+		},
+		"%w wrapped ValidationError": {
+			err: fmt.Errorf("error binding: %w", goboilerplate.NewValidationError(goboilerplate.FieldViolation{
+				Field: "title", Rule: "required", Message: "title is required",
+			})),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{
+				"code": "bad_request",
+				"message": "invalid input: title is required",
+				"violations": [{"field": "title", "rule": "required", "message": "title is required"}]
+			}`,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = handler.ErrorHandler
+			e.GET("/my-endpoint", func(c echo.Context) error {
+				return test.err
+			})
+
+			logrus.SetOutput(io.Discard)
+
+			req := httptest.NewRequest(echo.GET, "/my-endpoint", nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			require.Equal(t, test.expectedStatus, rec.Code)
+			require.JSONEq(t, test.expectedBody, rec.Body.String())
+		})
+	}
+}
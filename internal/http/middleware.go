@@ -2,10 +2,12 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 // TimeoutMiddleware is a middleware that set maximum HTTP response time before considered timeout.
@@ -30,5 +32,29 @@ func URLSkipper(c echo.Context) bool {
 	case "/ping", "/_version", "/metrics":
 		return true
 	}
-	return strings.HasPrefix(c.Path(), "/debug")
+	return strings.HasPrefix(c.Path(), "/debug") || strings.HasPrefix(c.Path(), "/admin")
+}
+
+// RateLimitSkipper skips the URLs skipped by URLSkipper but /admin, so that its bearer token could not be brute-forced.
+func RateLimitSkipper(c echo.Context) bool {
+	return URLSkipper(c) && !strings.HasPrefix(c.Path(), "/admin")
+}
+
+// BearerTokenMiddleware is a middleware rejecting the requests without "Authorization: Bearer <token>".
+func BearerTokenMiddleware(token string) echo.MiddlewareFunc {
+	return middleware.KeyAuth(func(key string, c echo.Context) (bool, error) {
+		return subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+	})
+}
+
+// RouteName returns the name of the route matched by the request, or "UNKNOWN".
+func RouteName(c echo.Context) string {
+	operation := "UNKNOWN"
+	for _, r := range c.Echo().Routes() {
+		if r.Method == c.Request().Method && r.Path == c.Path() {
+			operation = r.Name
+		}
+	}
+
+	return operation
 }
@@ -128,21 +128,12 @@ func NewPrometheus(serviceName string, skipper middleware.Skipper) *Prometheus {
 	}
 
 	p := &Prometheus{
-		MetricsList: defaultMetrics,
-		MetricsPath: defaultMetricPath,
-		ServiceName: serviceName,
-		Subsystem:   "http",
-		Skipper:     skipper,
-		OperationLabelMappingFunc: func(c echo.Context) string {
-			operation := "UNKNOWN"
-			for _, r := range c.Echo().Routes() {
-				if r.Method == c.Request().Method && r.Path == c.Path() {
-					operation = r.Name
-				}
-			}
-
-			return operation
-		},
+		MetricsList:               defaultMetrics,
+		MetricsPath:               defaultMetricPath,
+		ServiceName:               serviceName,
+		Subsystem:                 "http",
+		Skipper:                   skipper,
+		OperationLabelMappingFunc: RouteName,
 	}
 
 	p.registerMetrics()
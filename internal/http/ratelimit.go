@@ -0,0 +1,150 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// The clients a rate limit rule is counted by.
+const (
+	RateLimitByIP     = "ip"
+	RateLimitByAPIKey = "api_key"
+	RateLimitByRoute  = "route"
+)
+
+// RateLimitRule limits the requests of every client IP, every API key or every route.
+type RateLimitRule struct {
+	// Name identifies the counters of the rule, defaulting to its definition. Counters are kept when the rules are
+	// reordered, and restart when a rule without name is changed.
+	Name  string
+	By    string
+	Limit goboilerplate.RateLimit
+	// Routes are the names of the routes the rule applies to, all of them when empty.
+	Routes []string
+}
+
+// RateLimitConfig is the configuration of RateLimitMiddleware.
+type RateLimitConfig struct {
+	Skipper middleware.Skipper
+	Rules   []RateLimitRule
+	// APIKeyHeader is the header holding the API key, X-API-Key by default.
+	APIKeyHeader string
+}
+
+// RateLimitMiddleware is a middleware limiting the rate of requests with limiter. Every rule matching the request
+// is counted, and the request is rejected with 429 Too Many Requests as soon as one of them is exceeded.
+// The RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers describe the most restrictive rule, along with
+// Retry-After when rejected. Requests are let through when limiter fails.
+func RateLimitMiddleware(limiter goboilerplate.RateLimiter, conf RateLimitConfig) echo.MiddlewareFunc {
+	if conf.Skipper == nil {
+		conf.Skipper = URLSkipper
+	}
+	if conf.APIKeyHeader == "" {
+		conf.APIKeyHeader = "X-API-Key"
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if conf.Skipper(c) {
+				return next(c)
+			}
+
+			routeName := RouteName(c)
+
+			var restrictive *goboilerplate.RateLimitResult
+			for _, rule := range conf.Rules {
+				if !rule.appliesTo(routeName) {
+					continue
+				}
+
+				client := rateLimitClient(c, rule.By, routeName, conf.APIKeyHeader)
+				if client == "" {
+					continue
+				}
+
+				res, err := limiter.Allow(c.Request().Context(), rule.id()+"###"+client, rule.Limit)
+				if err != nil {
+					logrus.Warnf("Error rate limiting '%s' by %s, letting it through: %+v", client, rule.By, err)
+					continue
+				}
+
+				if restrictive == nil || !res.Allowed || res.Remaining < restrictive.Remaining {
+					restrictive = &res
+				}
+				if !res.Allowed {
+					break
+				}
+			}
+
+			if restrictive == nil {
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(restrictive.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(restrictive.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(restrictive.ResetAfter))
+
+			if !restrictive.Allowed {
+				header.Set("Retry-After", ceilSeconds(restrictive.RetryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests")
+			}
+			return next(c)
+		}
+	}
+}
+
+// id returns the identity of the rule, its name or its definition, e.g. "ip:100/1m0s:20@getArticle|fetchArticles".
+func (r RateLimitRule) id() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s:%d/%s:%d@%s", r.By, r.Limit.Rate, r.Limit.Period, r.Limit.Burst, strings.Join(r.Routes, "|"))
+}
+
+func (r RateLimitRule) appliesTo(routeName string) bool {
+	if len(r.Routes) == 0 {
+		return true
+	}
+	for _, route := range r.Routes {
+		if route == routeName {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitClient returns the client the request is counted for, empty when the rule does not apply.
+func rateLimitClient(c echo.Context, by string, routeName string, apiKeyHeader string) string {
+	switch by {
+	case RateLimitByIP:
+		return "ip###" + c.RealIP()
+	case RateLimitByAPIKey:
+		apiKey := c.Request().Header.Get(apiKeyHeader)
+		if apiKey == "" {
+			return ""
+		}
+		// hashed, to keep the keys out of redis
+		sum := sha256.Sum256([]byte(apiKey))
+		return "api_key###" + hex.EncodeToString(sum[:16])
+	case RateLimitByRoute:
+		return "route###" + routeName
+	}
+	return ""
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,140 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	handler "github.com/kurio/boilerplate-go/internal/http"
+)
+
+// countingRateLimiter allows Burst requests per key, without ever refilling.
+type countingRateLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (l *countingRateLimiter) Allow(
+	ctx context.Context,
+	key string,
+	limit goboilerplate.RateLimit,
+) (goboilerplate.RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counts[key]++
+	remaining := limit.Burst - l.counts[key]
+	result := goboilerplate.RateLimitResult{Limit: limit.Burst, ResetAfter: time.Second}
+	if remaining < 0 {
+		result.RetryAfter = 1500 * time.Millisecond
+		return result, nil
+	}
+	result.Allowed = true
+	result.Remaining = remaining
+	return result, nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = handler.ErrorHandler
+	e.Use(handler.RateLimitMiddleware(&countingRateLimiter{counts: map[string]int{}}, handler.RateLimitConfig{
+		Rules: []handler.RateLimitRule{
+			{By: handler.RateLimitByIP, Limit: goboilerplate.RateLimit{Burst: 3}},
+			{By: handler.RateLimitByAPIKey, Limit: goboilerplate.RateLimit{Burst: 1}, Routes: []string{"limited"}},
+		},
+	}))
+	e.GET("/limited", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}).Name = "limited"
+	e.GET("/other", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}).Name = "other"
+
+	do := func(path string, ip string, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/other", "10.0.0.1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "2", rec.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "1", rec.Header().Get("RateLimit-Reset"))
+
+	// the API key rule is the most restrictive
+	rec = do("/limited", "10.0.0.1", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = do("/limited", "10.0.0.2", "secret")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "2", rec.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"code":"too_many_requests","message":"too many requests"}`, rec.Body.String())
+
+	// without API key, only the IP rule applies
+	rec = do("/limited", "10.0.0.1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do("/other", "10.0.0.1", "")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitMiddleware_ReorderedRules(t *testing.T) {
+	limiter := &countingRateLimiter{counts: map[string]int{}}
+	perIP := handler.RateLimitRule{By: handler.RateLimitByIP, Limit: goboilerplate.RateLimit{Burst: 2}}
+	perRoute := handler.RateLimitRule{By: handler.RateLimitByRoute, Limit: goboilerplate.RateLimit{Burst: 5}}
+
+	// the counters of a rule are shared by the replicas, whatever the order of their rules
+	newServer := func(rules ...handler.RateLimitRule) *echo.Echo {
+		e := echo.New()
+		e.HTTPErrorHandler = handler.ErrorHandler
+		e.Use(handler.RateLimitMiddleware(limiter, handler.RateLimitConfig{Rules: rules}))
+		e.GET("/limited", func(c echo.Context) error {
+			return c.String(http.StatusOK, "ok")
+		}).Name = "limited"
+		return e
+	}
+	replica1 := newServer(perIP, perRoute)
+	replica2 := newServer(perRoute, perIP)
+
+	do := func(e *echo.Echo) int {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, do(replica1))
+	require.Equal(t, http.StatusOK, do(replica2))
+	require.Equal(t, http.StatusTooManyRequests, do(replica2))
+}
+
+func TestRateLimitSkipper(t *testing.T) {
+	e := echo.New()
+	for path, expected := range map[string]bool{
+		"/ping":             true,
+		"/debug/pprof/":     true,
+		"/admin/cache/:key": false,
+		"/articles/:id":     false,
+	} {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.SetPath(path)
+		require.Equal(t, expected, handler.RateLimitSkipper(c), path)
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// redacted replaces the masked headers and values.
+const redacted = "[REDACTED]"
+
+// RedactionPolicy tells which request headers and values are masked before being logged.
+type RedactionPolicy struct {
+	// DeniedHeaders are always masked.
+	DeniedHeaders []string
+	// AllowedHeaders are the only headers logged, masked by ValueMasks, when not empty. The others are masked.
+	AllowedHeaders []string
+	// ValueMasks are masked in every logged value, e.g. the headers, the URI and the error. When a mask has a
+	// capturing group, only the text matched by the group is masked, e.g. the value of a query parameter.
+	ValueMasks []*regexp.Regexp
+}
+
+// secretParams are the names of the query parameters masked by DefaultRedactionPolicy.
+var secretParams = []string{
+	"access_token", "refresh_token", "id_token", "token",
+	"api_key", "apikey", "key",
+	"password", "passwd", "secret", "client_secret",
+	"signature", "sig",
+}
+
+// DefaultRedactionPolicy returns the policy masking the usual credentials: the authentication, cookie, API key and
+// token headers, bearer credentials, JSON web tokens and the secret query parameters.
+func DefaultRedactionPolicy() RedactionPolicy {
+	return RedactionPolicy{
+		DeniedHeaders: []string{
+			"Authorization",
+			"Proxy-Authorization",
+			"Cookie",
+			"Set-Cookie",
+			"X-API-Key",
+			"X-Auth-Token",
+			"X-Access-Token",
+			"X-CSRF-Token",
+			"X-XSRF-Token",
+			"X-Amz-Security-Token",
+		},
+		ValueMasks: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`),
+			regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+			regexp.MustCompile(`(?i)\b(?:` + strings.Join(secretParams, "|") + `)=([^&\s"']+)`),
+		},
+	}
+}
+
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns the value of the header name as it should be logged.
+func (p RedactionPolicy) Header(name string, value string) string {
+	if containsHeader(p.DeniedHeaders, name) {
+		return redacted
+	}
+	if len(p.AllowedHeaders) > 0 && !containsHeader(p.AllowedHeaders, name) {
+		return redacted
+	}
+	return p.Value(value)
+}
+
+// Headers formats the headers as they should be logged, as "name:value | name:value" sorted by name.
+func (p RedactionPolicy) Headers(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]string, len(names))
+	for i, name := range names {
+		headers[i] = fmt.Sprintf(`%s:%s`, name, p.Header(name, strings.Join(header[name], ",")))
+	}
+	return strings.Join(headers, " | ")
+}
+
+// Value returns s with the matches of the value masks redacted.
+func (p RedactionPolicy) Value(s string) string {
+	for _, mask := range p.ValueMasks {
+		if mask.NumSubexp() == 0 {
+			s = mask.ReplaceAllLiteralString(s, redacted)
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, match := range mask.FindAllStringSubmatchIndex(s, -1) {
+			// match[2:4] is the first capturing group, -1 when it did not participate
+			start, end := match[0], match[1]
+			if match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(redacted)
+			last = end
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// RequestLoggerMiddleware is a middleware logging every request, with its URI redacted by policy.
+func RequestLoggerMiddleware(skipper middleware.Skipper, policy RedactionPolicy) echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		Skipper:      skipper,
+		LogURI:       true,
+		LogRoutePath: true,
+		LogStatus:    true,
+		LogLatency:   true,
+		LogValuesFunc: func(c echo.Context, values middleware.RequestLoggerValues) error {
+			logrus.WithFields(logrus.Fields{
+				"URI":        policy.Value(values.URI),
+				"status":     values.Status,
+				"latency_ms": fmt.Sprintf("%.3f", float64(values.Latency)/float64(time.Millisecond)),
+				"route":      values.RoutePath,
+			}).Info("request info")
+
+			return nil
+		},
+	})
+}
@@ -0,0 +1,367 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// cachedResponse is a response stored in the cache. When the response varies on some request headers, the entry of
+// the URL only holds Vary, and the response is stored under a key including the values of these headers.
+type cachedResponse struct {
+	Vary   []string    `json:"vary,omitempty"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	// Expires is the end of the freshness lifetime given by the response, if any.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// uncachedHeaders are the response headers never stored.
+var uncachedHeaders = []string{"Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade", "Date", "Content-Length"}
+
+// ResponseCacheConfig is the configuration of ResponseCacheMiddleware.
+type ResponseCacheConfig struct {
+	Skipper middleware.Skipper
+	// Expiration is the expiry tier of the cached responses.
+	Expiration goboilerplate.ExpiryDuration
+	// Redaction masks the secrets of the logged URI and error, DefaultRedactionPolicy when nil.
+	Redaction *RedactionPolicy
+}
+
+// ResponseCacheMiddleware is a middleware caching the successful GET responses in cacher, keyed by URL and by the
+// request headers listed in Vary. Apply it per route to set the expiry tier of each route.
+//
+// Responses are not stored when they set cookies, vary on every header (Vary: *), or have Cache-Control private,
+// no-cache or no-store. Requests with Cache-Control no-cache skip the cached response and no-store skip the cache
+// altogether. ETag and Last-Modified are added when missing, and conditional requests are answered with 304.
+//
+// As a shared cache (RFC 9111), the requests with Authorization or cookies are only served from and stored to the
+// cache when the response has Cache-Control public or s-maxage. The freshness lifetime of the response, from its
+// s-maxage, max-age or Expires, is honoured within the expiry tier, which stays the upper bound.
+func ResponseCacheMiddleware(cacher goboilerplate.Cacher, conf ResponseCacheConfig) echo.MiddlewareFunc {
+	if conf.Skipper == nil {
+		conf.Skipper = middleware.DefaultSkipper
+	}
+	if conf.Expiration == "" {
+		conf.Expiration = goboilerplate.DurationShort
+	}
+	redaction := DefaultRedactionPolicy()
+	if conf.Redaction != nil {
+		redaction = *conf.Redaction
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if conf.Skipper(c) || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+				return next(c)
+			}
+
+			requestDirectives := cacheControlDirectives(req.Header.Get("Cache-Control"))
+			if requestDirectives.has("no-store") {
+				return next(c)
+			}
+
+			authenticated := req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != ""
+			key := "response:" + req.URL.RequestURI()
+
+			if !requestDirectives.has("no-cache") {
+				res, err := getCachedResponse(c, cacher, key)
+				switch {
+				case err == nil && (!authenticated || isShared(res.Header)):
+					c.Response().Header().Set("X-Cache", "HIT")
+					return writeResponse(c, res)
+				case err != nil && !errors.Is(err, goboilerplate.ErrNotFound):
+					logrus.Warn(redaction.Value(fmt.Sprintf("Error getting cached response of '%s': %+v", key, err)))
+				}
+			}
+
+			rec := &responseRecorder{ResponseWriter: c.Response().Writer, status: http.StatusOK}
+			c.Response().Writer = rec
+			err := next(c)
+			c.Response().Writer = rec.ResponseWriter
+			if err != nil && !c.Response().Committed {
+				return err
+			}
+
+			res := cachedResponse{
+				Status: rec.status,
+				Header: c.Response().Header().Clone(),
+				Body:   rec.body.Bytes(),
+			}
+			if rec.status == http.StatusOK && req.Method == http.MethodGet {
+				setValidators(c.Response().Header(), &res)
+				if isStorable(res.Header, authenticated) {
+					if lifetime, ok := freshnessLifetime(res.Header); ok {
+						expires := time.Now().Add(lifetime)
+						res.Expires = &expires
+					}
+					if err := setCachedResponse(c, cacher, key, res, conf.Expiration); err != nil {
+						logrus.Warn(redaction.Value(fmt.Sprintf("Error caching response of '%s': %+v", key, err)))
+					}
+				}
+			}
+
+			c.Response().Header().Set("X-Cache", "MISS")
+			if writeErr := writeRecorded(c, res); writeErr != nil {
+				return writeErr
+			}
+			return err
+		}
+	}
+}
+
+// responseRecorder buffers the response, so that it could be stored and validated before being sent.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+// Flush does nothing: the response is only sent once the handler returns, so streamed responses must not go through
+// ResponseCacheMiddleware.
+func (r *responseRecorder) Flush() {}
+
+// cacheControl holds the directives of a Cache-Control header by lower-cased name, along with their value if any.
+type cacheControl map[string]string
+
+func cacheControlDirectives(header string) cacheControl {
+	directives := make(cacheControl)
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// isShared tells whether the response could be shared by the requests with Authorization or cookies.
+func isShared(header http.Header) bool {
+	directives := cacheControlDirectives(header.Get("Cache-Control"))
+	return directives.has("public") || directives.has("s-maxage")
+}
+
+func isStorable(header http.Header, authenticated bool) bool {
+	if header.Get("Set-Cookie") != "" || header.Get("Vary") == "*" {
+		return false
+	}
+	if authenticated && !isShared(header) {
+		return false
+	}
+	if lifetime, ok := freshnessLifetime(header); ok && lifetime <= 0 {
+		return false
+	}
+	directives := cacheControlDirectives(header.Get("Cache-Control"))
+	return !directives.has("private") && !directives.has("no-cache") && !directives.has("no-store")
+}
+
+// freshnessLifetime returns the freshness lifetime given by the response to a shared cache, from s-maxage, max-age
+// or Expires in this order. An invalid value means the response is already stale.
+func freshnessLifetime(header http.Header) (lifetime time.Duration, ok bool) {
+	directives := cacheControlDirectives(header.Get("Cache-Control"))
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, found := directives[name]; found {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0, true
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, true
+		}
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// varyHeaders returns the canonical names of the request headers listed in the Vary header.
+func varyHeaders(header http.Header) (names []string) {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+func variantKey(key string, vary []string, req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteString("###")
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+func getCachedResponse(c echo.Context, cacher goboilerplate.Cacher, key string) (res cachedResponse, err error) {
+	res, err = getCachedEntry(c, cacher, key)
+	if err != nil || len(res.Vary) == 0 {
+		return
+	}
+
+	res, err = getCachedEntry(c, cacher, variantKey(key, res.Vary, c.Request()))
+	if err == nil && res.Status == 0 {
+		err = goboilerplate.ErrNotFound
+	}
+	return
+}
+
+func getCachedEntry(c echo.Context, cacher goboilerplate.Cacher, key string) (res cachedResponse, err error) {
+	var raw string
+	raw, err = cacher.Get(c.Request().Context(), key)
+	if err != nil {
+		return
+	}
+
+	if err = json.Unmarshal([]byte(raw), &res); err != nil {
+		err = errors.Wrap(err, "error unmarshalling cached response")
+		return
+	}
+	if res.Expires != nil && !time.Now().Before(*res.Expires) {
+		err = goboilerplate.ErrNotFound
+	}
+	return
+}
+
+func setCachedResponse(
+	c echo.Context,
+	cacher goboilerplate.Cacher,
+	key string,
+	res cachedResponse,
+	expiration goboilerplate.ExpiryDuration,
+) error {
+	ctx := c.Request().Context()
+
+	for _, name := range uncachedHeaders {
+		res.Header.Del(name)
+	}
+	res.Header.Del("X-Cache")
+
+	if vary := varyHeaders(res.Header); len(vary) > 0 {
+		data, err := json.Marshal(cachedResponse{Vary: vary})
+		if err != nil {
+			return errors.Wrap(err, "error marshalling vary entry")
+		}
+		if err := cacher.Set(ctx, key, string(data), expiration); err != nil {
+			return err
+		}
+		key = variantKey(key, vary, c.Request())
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return errors.Wrap(err, "error marshalling response")
+	}
+	return cacher.Set(ctx, key, string(data), expiration)
+}
+
+// setValidators sets the ETag, from the body, and the Last-Modified headers when the handler did not.
+func setValidators(header http.Header, res *cachedResponse) {
+	if header.Get("ETag") == "" {
+		sum := sha256.Sum256(res.Body)
+		header.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	}
+	if header.Get("Last-Modified") == "" {
+		header.Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	}
+	res.Header.Set("ETag", header.Get("ETag"))
+	res.Header.Set("Last-Modified", header.Get("Last-Modified"))
+}
+
+// notModified tells whether the conditional request matches the response validators.
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		etag := strings.TrimPrefix(header.Get("ETag"), "W/")
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || (etag != "" && candidate == etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ims)
+}
+
+func writeResponse(c echo.Context, res cachedResponse) error {
+	header := c.Response().Header()
+	for name, values := range res.Header {
+		header[name] = values
+	}
+
+	if notModified(c.Request(), header) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(res.Status)
+	}
+	c.Response().WriteHeader(res.Status)
+	_, err := c.Response().Write(res.Body)
+	return err
+}
+
+// writeRecorded sends the recorded response once the recorder is unwrapped. The response, committed while recorded,
+// is reset first so that its status and size are the ones sent, e.g. for the logs.
+func writeRecorded(c echo.Context, res cachedResponse) error {
+	c.Response().Committed = false
+	c.Response().Size = 0
+
+	if res.Status == http.StatusOK && notModified(c.Request(), c.Response().Header()) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(res.Status)
+	}
+	c.Response().WriteHeader(res.Status)
+	_, err := c.Response().Write(res.Body)
+	return err
+}
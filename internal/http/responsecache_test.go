@@ -0,0 +1,176 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	handler "github.com/kurio/boilerplate-go/internal/http"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = handler.ErrorHandler
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+	responseCache := handler.ResponseCacheMiddleware(cacher, handler.ResponseCacheConfig{})
+
+	// the status and size seen by the outer middlewares, e.g. the logger
+	var sent *echo.Response
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			sent = c.Response()
+			return err
+		}
+	})
+
+	calls := map[string]int{}
+	e.GET("/articles/:id", func(c echo.Context) error {
+		calls["article"]++
+		return c.JSON(http.StatusOK, map[string]interface{}{"id": c.Param("id")})
+	}, responseCache)
+	e.GET("/localized", func(c echo.Context) error {
+		calls["localized"]++
+		c.Response().Header().Set("Vary", "Accept-Language")
+		return c.String(http.StatusOK, c.Request().Header.Get("Accept-Language"))
+	}, responseCache)
+	e.GET("/session", func(c echo.Context) error {
+		calls["session"]++
+		c.SetCookie(&http.Cookie{Name: "session", Value: "secret"})
+		return c.String(http.StatusOK, "ok")
+	}, responseCache)
+	e.GET("/me", func(c echo.Context) error {
+		calls["me"]++
+		return c.String(http.StatusOK, c.Request().Header.Get("Authorization"))
+	}, responseCache)
+	e.GET("/public", func(c echo.Context) error {
+		calls["public"]++
+		c.Response().Header().Set("Cache-Control", "public, max-age=60")
+		return c.String(http.StatusOK, "public")
+	}, responseCache)
+	e.GET("/fresh", func(c echo.Context) error {
+		calls["fresh"]++
+		c.Response().Header().Set("Cache-Control", c.QueryParam("cache_control"))
+		c.Response().Header().Set("Expires", c.QueryParam("expires"))
+		return c.String(http.StatusOK, "fresh")
+	}, responseCache)
+	e.GET("/missing", func(c echo.Context) error {
+		calls["missing"]++
+		return goboilerplate.ErrNotFound
+	}, responseCache)
+
+	do := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		rec := do("/articles/1", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		etag := rec.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		require.NotEmpty(t, rec.Header().Get("Last-Modified"))
+
+		rec = do("/articles/1", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+		require.Equal(t, etag, rec.Header().Get("ETag"))
+		require.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"id":"1"}`, rec.Body.String())
+		require.Equal(t, 1, calls["article"])
+
+		rec = do("/articles/1", map[string]string{"If-None-Match": etag})
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		require.Empty(t, rec.Body.String())
+
+		rec = do("/articles/1", map[string]string{"Cache-Control": "no-cache"})
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		require.Equal(t, 2, calls["article"])
+		require.Equal(t, http.StatusOK, sent.Status)
+		require.Equal(t, int64(rec.Body.Len()), sent.Size)
+
+		rec = do("/articles/1", map[string]string{"Cache-Control": "no-cache", "If-None-Match": etag})
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		require.Equal(t, http.StatusNotModified, rec.Code)
+		require.Empty(t, rec.Body.String())
+		require.Equal(t, http.StatusNotModified, sent.Status)
+		require.Zero(t, sent.Size)
+		require.Equal(t, 3, calls["article"])
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		rec := do("/localized", map[string]string{"Accept-Language": "id"})
+		require.Equal(t, "id", rec.Body.String())
+		rec = do("/localized", map[string]string{"Accept-Language": "en"})
+		require.Equal(t, "en", rec.Body.String())
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+		rec = do("/localized", map[string]string{"Accept-Language": "id"})
+		require.Equal(t, "id", rec.Body.String())
+		require.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+		require.Equal(t, 2, calls["localized"])
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		rec := do("/me", map[string]string{"Authorization": "Bearer one"})
+		require.Equal(t, "Bearer one", rec.Body.String())
+		rec = do("/me", map[string]string{"Authorization": "Bearer two"})
+		require.Equal(t, "Bearer two", rec.Body.String())
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+		// stored for the anonymous requests only, never served to the authenticated ones
+		do("/me", nil)
+		rec = do("/me", map[string]string{"Cookie": "session=secret"})
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		require.Equal(t, 4, calls["me"])
+
+		do("/public", map[string]string{"Authorization": "Bearer one"})
+		rec = do("/public", map[string]string{"Authorization": "Bearer two"})
+		require.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+		require.Equal(t, 1, calls["public"])
+	})
+
+	t.Run("freshness", func(t *testing.T) {
+		expired := url.QueryEscape(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		for _, query := range []string{
+			"cache_control=max-age%3D0",
+			"cache_control=s-maxage%3D0%2C+max-age%3D60",
+			"expires=" + expired,
+			"expires=invalid",
+		} {
+			do("/fresh?"+query, nil)
+			rec := do("/fresh?"+query, nil)
+			require.Equal(t, "MISS", rec.Header().Get("X-Cache"), query)
+		}
+		require.Equal(t, 8, calls["fresh"])
+
+		do("/fresh?cache_control=max-age%3D60", nil)
+		rec := do("/fresh?cache_control=max-age%3D60", nil)
+		require.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+	})
+
+	t.Run("not stored", func(t *testing.T) {
+		do("/session", nil)
+		rec := do("/session", nil)
+		require.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		require.Equal(t, 2, calls["session"])
+
+		do("/missing", nil)
+		rec = do("/missing", nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Equal(t, 2, calls["missing"])
+	})
+}
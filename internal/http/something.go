@@ -1,20 +1,27 @@
 package http
 
 import (
-	"encoding/json"
+	"context"
 	"math/rand"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/metric"
 
 	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
 )
 
-func AddSomeHandler(e *echo.Echo, cacher goboilerplate.Cacher) {
+func AddSomeHandler(
+	e *echo.Echo,
+	loader *cache.Loader,
+	cacher goboilerplate.Cacher,
+	redaction RedactionPolicy,
+	meterProvider metric.MeterProvider,
+) {
 	g := e.Group("/something")
 
 	g.GET("/:duration", func(c echo.Context) error {
@@ -32,39 +39,26 @@ func AddSomeHandler(e *echo.Echo, cacher goboilerplate.Cacher) {
 
 	e.GET("/articles", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, make([]interface{}, 0))
-	}).Name = "fetchArticles"
+	}, ResponseCacheMiddleware(cacher, ResponseCacheConfig{
+		Expiration: goboilerplate.DurationShort,
+		Redaction:  &redaction,
+	})).Name = "fetchArticles"
+
+	articles := cache.NewTypedCache[map[string]interface{}](loader, cache.JSONCodec{}, meterProvider)
 
 	e.GET("/articles/:id", func(c echo.Context) error {
 		articleID := c.Param("id")
 
-		var err error
-		articleStr, err := cacher.Get(c.Request().Context(), articleID)
-		if err == nil {
-			var article map[string]interface{}
-			err := json.Unmarshal([]byte(articleStr), &article)
-			if err == nil {
-				return c.JSON(http.StatusOK, article)
-			}
-		}
-
-		if errors.Cause(err) != goboilerplate.ErrNotFound {
-			logrus.Warnf("Error getting article '%s' from cache: %+v", articleID, err)
-		}
-
-		time.Sleep(time.Duration(rand.Intn(200)+100) * time.Millisecond) // simulate getting data from storage
-		article := map[string]interface{}{"id": articleID}
-
-		articleBytes, err := json.Marshal(article)
-		if err != nil {
-			logrus.Warnf("Error marshalling article to JSON: %+v", err)
-			return c.JSON(http.StatusOK, article)
+		load := func(ctx context.Context) (map[string]interface{}, error) {
+			time.Sleep(time.Duration(rand.Intn(200)+100) * time.Millisecond) // simulate getting data from storage
+			return map[string]interface{}{"id": articleID}, nil
 		}
-
-		err = cacher.Set(c.Request().Context(), articleID, string(articleBytes), goboilerplate.DurationShort)
+		article, err := articles.GetOrLoad(c.Request().Context(), "article:"+articleID, goboilerplate.DurationShort, load)
 		if err != nil {
-			logrus.Warnf("Error setting article '%s' to cache: %+v", articleID, err)
+			return err
 		}
 
+		// already cached by articles, so not through ResponseCacheMiddleware, which would outlive its invalidation
 		return c.JSON(http.StatusOK, article)
 	}).Name = "getArticle"
 }
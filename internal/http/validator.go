@@ -0,0 +1,158 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+type structValidator struct {
+	validate *validator.Validate
+}
+
+// NewValidator is a constructor for the echo.Validator checking the `validate` struct tags, see
+// github.com/go-playground/validator. Every broken constraint is reported in a goboilerplate.ValidationError, with
+// the fields named after their json tag.
+func NewValidator() echo.Validator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+
+	return structValidator{
+		validate: validate,
+	}
+}
+
+func (v structValidator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return errors.Wrap(err, "error validating input")
+	}
+
+	violations := make([]goboilerplate.FieldViolation, len(fieldErrors))
+	for i, fieldError := range fieldErrors {
+		violations[i] = fieldViolation(fieldError)
+	}
+	return goboilerplate.NewValidationError(violations...)
+}
+
+func fieldViolation(fieldError validator.FieldError) goboilerplate.FieldViolation {
+	// the namespace starts with the name of the validated struct
+	_, field, _ := strings.Cut(fieldError.Namespace(), ".")
+
+	var params []string
+	switch {
+	case fieldError.Tag() == "oneof":
+		params = strings.Fields(fieldError.Param())
+	case fieldError.Param() != "":
+		params = []string{fieldError.Param()}
+	}
+
+	return goboilerplate.FieldViolation{
+		Field:   field,
+		Rule:    fieldError.Tag(),
+		Params:  params,
+		Message: violationMessage(field, fieldError),
+	}
+}
+
+func violationMessage(field string, fieldError validator.FieldError) string {
+	param := fieldError.Param()
+
+	var unit string
+	switch fieldError.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min", "gte":
+		if unit != "" {
+			return fmt.Sprintf("%s must have at least %s%s", field, param, unit)
+		}
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	case "max", "lte":
+		if unit != "" {
+			return fmt.Sprintf("%s must have at most %s%s", field, param, unit)
+		}
+		return fmt.Sprintf("%s must be at most %s", field, param)
+	case "len":
+		return fmt.Sprintf("%s must have %s%s", field, param, unit)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, strings.Join(strings.Fields(param), ", "))
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", field)
+	}
+
+	if param != "" {
+		return fmt.Sprintf("%s does not satisfy %s=%s", field, fieldError.Tag(), param)
+	}
+	return fmt.Sprintf("%s does not satisfy %s", field, fieldError.Tag())
+}
+
+type validatingBinder struct {
+	echo.DefaultBinder
+}
+
+// NewBinder is a constructor for the echo.Binder validating the bound structs with the echo.Validator, so that
+// c.Bind reports every invalid field at once in a goboilerplate.ValidationError. A JSON value of the wrong type is
+// reported the same way. The other values, such as slices or maps, are bound without validation.
+func NewBinder() echo.Binder {
+	return &validatingBinder{}
+}
+
+func (b *validatingBinder) Bind(i interface{}, c echo.Context) error {
+	if err := b.DefaultBinder.Bind(i, c); err != nil {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			if typeErr, ok := httpErr.Internal.(*json.UnmarshalTypeError); ok {
+				return goboilerplate.NewValidationError(goboilerplate.FieldViolation{
+					Field:   typeErr.Field,
+					Rule:    "type",
+					Params:  []string{typeErr.Type.String()},
+					Message: fmt.Sprintf("%s must be a %s, not a %s", typeErr.Field, typeErr.Type, typeErr.Value),
+				})
+			}
+		}
+		return err
+	}
+
+	if c.Echo().Validator == nil || !isStruct(i) {
+		return nil
+	}
+	return c.Validate(i)
+}
+
+// isStruct tells whether i is a struct or a pointer to one, the only values with `validate` tags.
+func isStruct(i interface{}) bool {
+	t := reflect.TypeOf(i)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
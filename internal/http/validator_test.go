@@ -0,0 +1,126 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	handler "github.com/kurio/boilerplate-go/internal/http"
+)
+
+type articleRequest struct {
+	Title  string   `json:"title" validate:"required,max=10"`
+	Status string   `json:"status" validate:"oneof=draft published"`
+	Tags   []string `json:"tags" validate:"max=2,dive,min=2"`
+	Author struct {
+		Age int `json:"age" validate:"gte=18"`
+	} `json:"author"`
+}
+
+func TestValidation(t *testing.T) {
+	e := echo.New()
+	e.Validator = handler.NewValidator()
+	e.Binder = handler.NewBinder()
+	e.HTTPErrorHandler = handler.ErrorHandler
+	e.POST("/articles", func(c echo.Context) error {
+		var req articleRequest
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, req)
+	})
+	e.POST("/articles/batch", func(c echo.Context) error {
+		var req []articleRequest
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, req)
+	})
+	e.POST("/articles/meta", func(c echo.Context) error {
+		var req map[string]string
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, req)
+	})
+
+	doPath := func(path string, body string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	do := func(body string, accept string) *httptest.ResponseRecorder {
+		return doPath("/articles", body, accept)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		rec := do(`{"title":"Hello","status":"draft","tags":["go"],"author":{"age":20}}`, "")
+		require.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("every violation", func(t *testing.T) {
+		rec := do(`{"title":"Hello, World!","status":"deleted","tags":["go","x","cache"],"author":{"age":17}}`, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		message := "invalid input: title must have at most 10 characters; status must be one of [draft, published]; " +
+			"tags must have at most 2 items; author.age must be at least 18"
+		require.JSONEq(t, `{
+			"code": "bad_request",
+			"message": "`+message+`",
+			"violations": [
+				{"field": "title", "rule": "max", "params": ["10"], "message": "title must have at most 10 characters"},
+				{
+					"field": "status",
+					"rule": "oneof",
+					"params": ["draft", "published"],
+					"message": "status must be one of [draft, published]"
+				},
+				{"field": "tags", "rule": "max", "params": ["2"], "message": "tags must have at most 2 items"},
+				{"field": "author.age", "rule": "gte", "params": ["18"], "message": "author.age must be at least 18"}
+			]
+		}`, rec.Body.String())
+	})
+
+	t.Run("nested violation", func(t *testing.T) {
+		rec := do(`{"status":"draft","tags":["x"],"author":{"age":18}}`, "application/problem+json")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Bad Request",
+			"status": 400,
+			"detail": "invalid input: title is required; tags[0] must have at least 2 characters",
+			"instance": "/articles",
+			"code": "bad_request",
+			"violations": [
+				{"field": "title", "rule": "required", "message": "title is required"},
+				{"field": "tags[0]", "rule": "min", "params": ["2"], "message": "tags[0] must have at least 2 characters"}
+			]
+		}`, rec.Body.String())
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		rec := do(`{"title":"Hello","status":"draft","author":{"age":"old"}}`, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Contains(t, rec.Body.String(), `"rule":"type","params":["int"]`)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		rec := doPath("/articles/batch", `[{"title":"Hello","status":"draft"}]`, "")
+		require.Equal(t, http.StatusCreated, rec.Code)
+		rec = doPath("/articles/meta", `{"source":"rss"}`, "")
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.JSONEq(t, `{"source":"rss"}`, rec.Body.String())
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		rec := do(`{"title":`, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NotContains(t, rec.Body.String(), "violations")
+	})
+}
@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+type entry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+	tags      []string
+}
+
+func (e *entry) size() int {
+	return len(e.key) + len(e.value)
+}
+
+type memoryCacher struct {
+	mu         sync.Mutex
+	expiryConf goboilerplate.ExpiryConf
+	maxEntries int
+	maxBytes   int
+
+	bytes     int
+	items     map[string]*list.Element
+	evictList *list.List
+	tags      map[string]map[string]struct{}
+}
+
+// NewMemoryCacher is a constructor for caching in the process memory.
+// The cache is bounded by maxEntries and maxBytes (key + value length), evicting the least recently used entries
+// first. A zero or negative bound means unlimited.
+// The returned cacher also implements goboilerplate.BatchCacher and goboilerplate.TagCacher.
+func NewMemoryCacher(expiryConf goboilerplate.ExpiryConf, maxEntries int, maxBytes int) goboilerplate.Cacher {
+	expiryConf.Set()
+
+	return &memoryCacher{
+		expiryConf: expiryConf,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		items:      make(map[string]*list.Element),
+		evictList:  list.New(),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *memoryCacher) Get(ctx context.Context, key string) (value string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		err = goboilerplate.ErrNotFound
+		return
+	}
+
+	e := elem.Value.(*entry)
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		c.removeElement(elem)
+		err = goboilerplate.ErrNotFound
+		return
+	}
+
+	c.evictList.MoveToFront(elem)
+	value = e.value
+	return
+}
+
+func (c *memoryCacher) Set(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+) (err error) {
+	return c.SetWithTags(ctx, key, value, expiration)
+}
+
+func (c *memoryCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) (err error) {
+	e := &entry{
+		key:   key,
+		value: value,
+		tags:  tags,
+	}
+	if ttl := c.expiryConf.TTL(expiration); ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+
+	// an entry that could never fit is not cached at all
+	if c.maxBytes > 0 && e.size() > c.maxBytes {
+		return
+	}
+
+	c.items[key] = c.evictList.PushFront(e)
+	c.bytes += e.size()
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.overCapacity() {
+		c.removeElement(c.evictList.Back())
+	}
+
+	return
+}
+
+func (c *memoryCacher) Del(ctx context.Context, key string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		err = goboilerplate.ErrNotFound
+		return
+	}
+
+	expired := elem.Value.(*entry).expiresAt
+	c.removeElement(elem)
+	if !expired.IsZero() && !time.Now().Before(expired) {
+		err = goboilerplate.ErrNotFound
+		return
+	}
+
+	return
+}
+
+func (c *memoryCacher) Flush(ctx context.Context) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.evictList.Init()
+	c.bytes = 0
+	c.tags = make(map[string]map[string]struct{})
+
+	return
+}
+
+func (c *memoryCacher) InvalidateTags(ctx context.Context, tags ...string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if elem, ok := c.items[key]; ok {
+				c.removeElement(elem)
+			}
+		}
+	}
+
+	return
+}
+
+func (c *memoryCacher) overCapacity() bool {
+	if c.maxEntries > 0 && c.evictList.Len() > c.maxEntries {
+		return true
+	}
+	return c.maxBytes > 0 && c.bytes > c.maxBytes
+}
+
+func (c *memoryCacher) removeElement(elem *list.Element) {
+	e := c.evictList.Remove(elem).(*entry)
+	delete(c.items, e.key)
+	c.bytes -= e.size()
+
+	for _, tag := range e.tags {
+		delete(c.tags[tag], e.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+func (c *memoryCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+		results[i].Value, results[i].Err = c.Get(ctx, key)
+	}
+	return
+}
+
+func (c *memoryCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(items))
+	for i, item := range items {
+		results[i].Key = item.Key
+		results[i].Value = item.Value
+		results[i].Err = c.Set(ctx, item.Key, item.Value, expiration)
+	}
+	return
+}
+
+func (c *memoryCacher) MDel(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+		results[i].Err = c.Del(ctx, key)
+	}
+	return
+}
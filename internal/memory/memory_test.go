@@ -0,0 +1,190 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cachetest"
+	"github.com/kurio/boilerplate-go/internal/memory"
+)
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := cacher.Get(ctx, "some-key")
+		require.Error(t, err)
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	})
+
+	t.Run("found", func(t *testing.T) {
+		require.NoError(t, cacher.Set(ctx, "some-key", "some-value", goboilerplate.DurationShort))
+
+		res, err := cacher.Get(ctx, "some-key")
+		require.NoError(t, err)
+		require.Equal(t, "some-value", res)
+	})
+}
+
+func TestExpiry(t *testing.T) {
+	ctx := context.Background()
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{
+		goboilerplate.DurationShort: {TTL: 10 * time.Millisecond},
+	}, 0, 0)
+
+	require.NoError(t, cacher.Set(ctx, "short", "v", goboilerplate.DurationShort))
+	require.NoError(t, cacher.Set(ctx, "long", "v", goboilerplate.DurationLong))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := cacher.Get(ctx, "short")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+
+	_, err = cacher.Get(ctx, "long")
+	require.NoError(t, err)
+}
+
+func TestDel(t *testing.T) {
+	ctx := context.Background()
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, cacher.Set(ctx, "some-key", "some-value", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Del(ctx, "some-key"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := cacher.Del(ctx, "some-key")
+		require.Error(t, err)
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	})
+}
+
+func TestFlush(t *testing.T) {
+	ctx := context.Background()
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+
+	require.NoError(t, cacher.Set(ctx, "k1", "v1", goboilerplate.DurationShort))
+	require.NoError(t, cacher.Set(ctx, "k2", "v2", goboilerplate.DurationShort))
+
+	require.NoError(t, cacher.Flush(ctx))
+
+	_, err := cacher.Get(ctx, "k1")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "k2")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+}
+
+func TestEviction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("by entry count", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 2, 0)
+
+		require.NoError(t, cacher.Set(ctx, "k1", "v1", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Set(ctx, "k2", "v2", goboilerplate.DurationShort))
+
+		// touch k1 so that k2 becomes the least recently used
+		_, err := cacher.Get(ctx, "k1")
+		require.NoError(t, err)
+
+		require.NoError(t, cacher.Set(ctx, "k3", "v3", goboilerplate.DurationShort))
+
+		_, err = cacher.Get(ctx, "k2")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+		_, err = cacher.Get(ctx, "k1")
+		require.NoError(t, err)
+		_, err = cacher.Get(ctx, "k3")
+		require.NoError(t, err)
+	})
+
+	t.Run("by byte size", func(t *testing.T) {
+		// each entry is 4 bytes
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 10)
+
+		require.NoError(t, cacher.Set(ctx, "k1", "v1", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Set(ctx, "k2", "v2", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Set(ctx, "k3", "v3", goboilerplate.DurationShort))
+
+		_, err := cacher.Get(ctx, "k1")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+		_, err = cacher.Get(ctx, "k3")
+		require.NoError(t, err)
+	})
+
+	t.Run("entry larger than max bytes", func(t *testing.T) {
+		cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 10)
+
+		require.NoError(t, cacher.Set(ctx, "k1", "v1", goboilerplate.DurationShort))
+		require.NoError(t, cacher.Set(ctx, "k1", "a-value-that-is-too-long", goboilerplate.DurationShort))
+
+		_, err := cacher.Get(ctx, "k1")
+		require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	})
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+
+	results := goboilerplate.MSet(ctx, cacher, []goboilerplate.KeyValue{
+		{Key: "k1", Value: "v1"},
+		{Key: "k2", Value: "v2"},
+	}, goboilerplate.DurationShort)
+	require.Len(t, results, 2)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+
+	results = goboilerplate.MGet(ctx, cacher, "k1", "missing", "k2")
+	require.Equal(t, []goboilerplate.BatchResult{
+		{Key: "k1", Value: "v1"},
+		{Key: "missing", Err: goboilerplate.ErrNotFound},
+		{Key: "k2", Value: "v2"},
+	}, results)
+
+	results = goboilerplate.MDel(ctx, cacher, "k1", "missing")
+	require.Equal(t, []goboilerplate.BatchResult{
+		{Key: "k1"},
+		{Key: "missing", Err: goboilerplate.ErrNotFound},
+	}, results)
+}
+
+func TestInvalidateTags(t *testing.T) {
+	ctx := context.Background()
+	cacher := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0).(goboilerplate.TagCacher)
+
+	article1Tags := []string{"author:42", "category:news"}
+	require.NoError(t, cacher.SetWithTags(ctx, "article:1", "v1", goboilerplate.DurationShort, article1Tags...))
+	require.NoError(t, cacher.SetWithTags(ctx, "article:2", "v2", goboilerplate.DurationShort, "author:7"))
+	require.NoError(t, cacher.SetWithTags(ctx, "articles:news", "v3", goboilerplate.DurationShort, "category:news"))
+
+	require.NoError(t, cacher.InvalidateTags(ctx, "author:42"))
+
+	_, err := cacher.Get(ctx, "article:1")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "article:2")
+	require.NoError(t, err)
+	_, err = cacher.Get(ctx, "articles:news")
+	require.NoError(t, err)
+
+	require.NoError(t, cacher.InvalidateTags(ctx, "category:news", "author:7"))
+
+	_, err = cacher.Get(ctx, "article:2")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "articles:news")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+}
+
+func TestConformance(t *testing.T) {
+	cachetest.Run(t, cachetest.Harness{
+		New: func(t *testing.T, expiryConf goboilerplate.ExpiryConf, keyPrefix string) goboilerplate.Cacher {
+			return memory.NewMemoryCacher(expiryConf, 0, 0)
+		},
+	})
+}
@@ -0,0 +1,164 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// getDataKeys returns the redis keys of keys, setting the error of the results whose key could not be resolved.
+// The indexes of the resolved keys are returned along.
+func (c redisCacher) getDataKeys(
+	ctx context.Context,
+	keys []string,
+	results []goboilerplate.BatchResult,
+) (dataKeys []string, indexes []int) {
+	for i, key := range keys {
+		dataKey, err := c.getDataKey(ctx, key)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		dataKeys = append(dataKeys, dataKey)
+		indexes = append(indexes, i)
+	}
+	return
+}
+
+// groupBySlot groups the indexes of the redis keys sharing the same cluster slot, so that each group could be sent
+// as a single multi-key command. Without cluster, all keys belong to the same group.
+func (c redisCacher) groupBySlot(dataKeys []string) [][]int {
+	if !c.cluster {
+		group := make([]int, len(dataKeys))
+		for i := range dataKeys {
+			group[i] = i
+		}
+		return [][]int{group}
+	}
+
+	var groups [][]int
+	slotToGroup := make(map[int]int)
+	for i, dataKey := range dataKeys {
+		slot := hashSlot(dataKey)
+		g, ok := slotToGroup[slot]
+		if !ok {
+			g = len(groups)
+			slotToGroup[slot] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// MGet gets all keys in a single pipeline, with one MGET per cluster slot.
+func (c redisCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+	}
+
+	dataKeys, indexes := c.getDataKeys(ctx, keys, results)
+	if len(dataKeys) == 0 {
+		return
+	}
+
+	groups := c.groupBySlot(dataKeys)
+	cmds := make([]*redis.SliceCmd, len(groups))
+	_, _ = c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for g, group := range groups {
+			groupKeys := make([]string, len(group))
+			for j, k := range group {
+				groupKeys[j] = dataKeys[k]
+			}
+			cmds[g] = pipe.MGet(ctx, groupKeys...)
+		}
+		return nil
+	})
+
+	for g, group := range groups {
+		values, err := cmds[g].Result()
+		for j, k := range group {
+			i := indexes[k]
+			switch {
+			case err != nil:
+				results[i].Err = errors.Wrap(err, "error getting data from redis")
+			case values[j] == nil:
+				results[i].Err = goboilerplate.ErrNotFound
+			default:
+				results[i].Value, _ = values[j].(string)
+			}
+		}
+	}
+	return
+}
+
+// MSet sets all items in a single pipeline.
+func (c redisCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(items))
+	keys := make([]string, len(items))
+	for i, item := range items {
+		results[i].Key = item.Key
+		results[i].Value = item.Value
+		keys[i] = item.Key
+	}
+
+	dataKeys, indexes := c.getDataKeys(ctx, keys, results)
+	if len(dataKeys) == 0 {
+		return
+	}
+
+	cmds := make([]*redis.StatusCmd, len(dataKeys))
+	_, _ = c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, dataKey := range dataKeys {
+			cmds[k] = pipe.Set(ctx, dataKey, items[indexes[k]].Value, c.expiryConf.TTL(expiration))
+		}
+		return nil
+	})
+
+	for k, i := range indexes {
+		if err := cmds[k].Err(); err != nil {
+			results[i].Err = errors.Wrap(err, "error setting data to redis")
+		}
+	}
+	return
+}
+
+// MDel deletes all keys in a single pipeline.
+func (c redisCacher) MDel(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = make([]goboilerplate.BatchResult, len(keys))
+	for i, key := range keys {
+		results[i].Key = key
+	}
+
+	dataKeys, indexes := c.getDataKeys(ctx, keys, results)
+	if len(dataKeys) == 0 {
+		return
+	}
+
+	cmds := make([]*redis.IntCmd, len(dataKeys))
+	_, _ = c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, dataKey := range dataKeys {
+			cmds[k] = pipe.Del(ctx, dataKey)
+		}
+		return nil
+	})
+
+	for k, i := range indexes {
+		count, err := cmds[k].Result()
+		switch {
+		case err != nil:
+			results[i].Err = errors.Wrap(err, "error deleting data from redis")
+		case count == 0:
+			results[i].Err = goboilerplate.ErrNotFound
+		}
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	_redis "github.com/go-redis/redis/v9"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cachetest"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+// newCacherFunc builds the tested cacher on top of redisClient.
+type newCacherFunc func(
+	redisClient _redis.UniversalClient,
+	expiryConf goboilerplate.ExpiryConf,
+	keyPrefix string,
+) goboilerplate.Cacher
+
+// miniredisHarness runs the cachers against an in-process redis, so that the conformance suite needs no service.
+func miniredisHarness(t *testing.T, newCacher newCacherFunc) cachetest.Harness {
+	server := miniredis.RunT(t)
+	redisClient := _redis.NewClient(&_redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, redisClient.Close())
+	})
+
+	return cachetest.Harness{
+		New: func(t *testing.T, expiryConf goboilerplate.ExpiryConf, keyPrefix string) goboilerplate.Cacher {
+			return newCacher(redisClient, expiryConf, keyPrefix)
+		},
+		Advance: func(t *testing.T, d time.Duration) {
+			server.FastForward(d)
+		},
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Run("redis", func(t *testing.T) {
+		cachetest.Run(t, miniredisHarness(t, redis.NewRedisCacher))
+	})
+
+	t.Run("namespaced", func(t *testing.T) {
+		cachetest.Run(t, miniredisHarness(t, func(
+			redisClient _redis.UniversalClient,
+			expiryConf goboilerplate.ExpiryConf,
+			keyPrefix string,
+		) goboilerplate.Cacher {
+			return redis.NewNamespacedRedisCacher(redisClient, expiryConf, keyPrefix, time.Second)
+		}))
+	})
+}
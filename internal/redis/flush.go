@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+const scanCount = 1000
+
+var globReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// matchPattern returns the SCAN pattern matching every key owned by the cacher.
+func (c redisCacher) matchPattern() string {
+	if c.keyPrefix == "" {
+		return "*"
+	}
+	return globReplacer.Replace(c.keyPrefix) + "###*"
+}
+
+// Flush deletes only the keys owned by the cacher, see FlushWithProgress.
+func (c redisCacher) Flush(ctx context.Context) (err error) {
+	deleted, err := c.FlushWithProgress(ctx, func(deleted int64) {
+		logrus.Debugf("Flushing '%s' cache: %d keys deleted", c.keyPrefix, deleted)
+	})
+	if err != nil {
+		return
+	}
+
+	logrus.Infof("Flushed '%s' cache: %d keys deleted", c.keyPrefix, deleted)
+	return
+}
+
+// FlushWithProgress deletes the keys having the cacher's key prefix, using SCAN and UNLINK so that redis is never
+// blocked. In cluster mode, every master is scanned. progress is called after each deleted batch, and must be safe
+// for concurrent use in cluster mode. Without key prefix, every key of the database would match, so it fails instead.
+func (c redisCacher) FlushWithProgress(
+	ctx context.Context,
+	progress goboilerplate.FlushProgressFunc,
+) (deleted int64, err error) {
+	if c.keyPrefix == "" {
+		err = errors.New("error flushing redis cache: refusing to delete every key, the cacher has no key prefix")
+		return
+	}
+
+	var mu sync.Mutex
+	report := func(count int64) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		deleted += count
+		if progress != nil {
+			progress(deleted)
+		}
+	}
+
+	if clusterClient, ok := c.redisClient.(*redis.ClusterClient); ok {
+		err = clusterClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return c.flushNode(ctx, client, report)
+		})
+	} else {
+		err = c.flushNode(ctx, c.redisClient, report)
+	}
+	if err != nil {
+		err = errors.Wrap(err, "error flushing redis cache")
+		return
+	}
+
+	return
+}
+
+func (c redisCacher) flushNode(ctx context.Context, client redis.UniversalClient, report func(count int64)) error {
+	keys := make([]string, 0, scanCount)
+
+	iter := client.Scan(ctx, 0, c.matchPattern(), scanCount).Iterator()
+	for iter.Next(ctx) {
+		if c.isNamespaceVersionKey(iter.Val()) {
+			continue
+		}
+		keys = append(keys, iter.Val())
+		if len(keys) < scanCount {
+			continue
+		}
+
+		count, err := unlink(ctx, client, keys)
+		if err != nil {
+			return err
+		}
+		report(count)
+		keys = keys[:0]
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	count, err := unlink(ctx, client, keys)
+	if err != nil {
+		return err
+	}
+	report(count)
+	return nil
+}
+
+// unlink deletes the keys with one UNLINK each, since keys on the same cluster node may be in different slots.
+func unlink(ctx context.Context, client redis.UniversalClient, keys []string) (count int64, err error) {
+	cmds, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	for _, cmd := range cmds {
+		count += cmd.(*redis.IntCmd).Val()
+	}
+	return
+}
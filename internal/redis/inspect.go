@@ -0,0 +1,288 @@
+package redis
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// errScanDone stops scanning once enough keys are found.
+var errScanDone = errors.New("scan done")
+
+// scanKeys calls fn with batches of the keys owned by the cacher, scanning every master in cluster mode. fn must be
+// safe for concurrent use in cluster mode, and must not retain keys.
+func (c redisCacher) scanKeys(
+	ctx context.Context,
+	fn func(ctx context.Context, client redis.UniversalClient, keys []string) error,
+) error {
+	scanNode := func(ctx context.Context, client redis.UniversalClient) error {
+		keys := make([]string, 0, scanCount)
+
+		iter := client.Scan(ctx, 0, c.matchPattern(), scanCount).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+			if len(keys) < scanCount {
+				continue
+			}
+
+			if err := fn(ctx, client, keys); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			return nil
+		}
+		return fn(ctx, client, keys)
+	}
+
+	if clusterClient, ok := c.redisClient.(*redis.ClusterClient); ok {
+		return clusterClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scanNode(ctx, client)
+		})
+	}
+	return scanNode(ctx, c.redisClient)
+}
+
+// parseStorageKey returns the application key of a redis key owned by the cacher, along with the namespace version it
+// was stored in, -1 if it's not namespaced.
+func (c redisCacher) parseStorageKey(fullKey string) (key string, namespace string, version int64) {
+	key = fullKey
+	if c.keyPrefix != "" {
+		key = strings.TrimPrefix(fullKey, c.keyPrefix+"###")
+	}
+
+	version = -1
+	if c.namespaces == nil || !strings.HasPrefix(key, "ns###") {
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, "ns###"), "###", 3)
+	if len(parts) != 3 || !strings.HasPrefix(parts[1], "v") {
+		return
+	}
+	v, err := strconv.ParseInt(strings.TrimPrefix(parts[1], "v"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	return parts[2], parts[0], v
+}
+
+// isInternalKey tells whether key is used by the cacher or its siblings, e.g. tag indexes or namespace versions.
+func isInternalKey(key string) bool {
+	return strings.Contains(key, "###")
+}
+
+// usageGroup returns the group a key is counted in: the kind of an internal key, e.g. "tag" or "ns", the
+// namespace of a key or "default".
+func usageGroup(key string) string {
+	if kind, _, ok := strings.Cut(key, "###"); ok {
+		return kind
+	}
+	if namespace, ok := goboilerplate.KeyNamespace(key); ok {
+		return namespace
+	}
+	return "default"
+}
+
+// globRegexp compiles a redis glob pattern, supporting '*', '?' and escaping with '\'.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`^(?s:`)
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString(`)$`)
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern '%s'", pattern)
+	}
+	return re, nil
+}
+
+// Inspect describes key, within the current version of its namespace.
+func (c redisCacher) Inspect(ctx context.Context, key string) (info goboilerplate.CacheKey, err error) {
+	dataKey, err := c.getDataKey(ctx, key)
+	if err != nil {
+		return
+	}
+
+	ttl, err := c.redisClient.PTTL(ctx, dataKey).Result()
+	if err != nil {
+		err = errors.Wrap(err, "error getting TTL from redis")
+		return
+	}
+
+	// -2 for a missing key, -1 for a key without expiry
+	if ttl == -2 {
+		err = goboilerplate.ErrNotFound
+		return
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	info = goboilerplate.CacheKey{
+		Key:        key,
+		StorageKey: dataKey,
+		TTL:        ttl,
+	}
+	return
+}
+
+// Keys scans the keys of the cacher for at most limit keys matching pattern, all of them when limit is not positive.
+// The internal keys and the keys of the previous namespace versions are left out, see MemoryUsage.
+func (c redisCacher) Keys(ctx context.Context, pattern string, limit int) (keys []goboilerplate.CacheKey, err error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return
+	}
+
+	var mu sync.Mutex
+	err = c.scanKeys(ctx, func(ctx context.Context, client redis.UniversalClient, fullKeys []string) error {
+		var matched []goboilerplate.CacheKey
+		for _, fullKey := range fullKeys {
+			key, namespace, version := c.parseStorageKey(fullKey)
+			if isInternalKey(key) || !re.MatchString(key) {
+				continue
+			}
+			if version >= 0 {
+				current, err := c.namespaceVersion(ctx, namespace)
+				if err != nil {
+					return err
+				}
+				if version != current {
+					continue
+				}
+			}
+			matched = append(matched, goboilerplate.CacheKey{Key: key, StorageKey: fullKey})
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+
+		pipe := client.Pipeline()
+		cmds := make([]*redis.DurationCmd, len(matched))
+		for i, key := range matched {
+			cmds[i] = pipe.PTTL(ctx, key.StorageKey)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for i, key := range matched {
+			ttl := cmds[i].Val()
+			if ttl == -2 {
+				continue
+			}
+			if ttl > 0 {
+				key.TTL = ttl
+			}
+
+			keys = append(keys, key)
+			if limit > 0 && len(keys) >= limit {
+				return errScanDone
+			}
+		}
+		return nil
+	})
+	if err != nil && errors.Cause(err) != errScanDone {
+		err = errors.Wrap(err, "error listing keys from redis")
+		return
+	}
+	err = nil
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+	return
+}
+
+// MemoryUsage sums the memory usage of every key of the cacher, grouped by namespace and by kind of internal key,
+// the largest group first. The keys of the previous namespace versions are counted until they expire.
+func (c redisCacher) MemoryUsage(ctx context.Context) (usage []goboilerplate.CacheUsage, err error) {
+	var mu sync.Mutex
+	groups := make(map[string]*goboilerplate.CacheUsage)
+
+	err = c.scanKeys(ctx, func(ctx context.Context, client redis.UniversalClient, fullKeys []string) error {
+		pipe := client.Pipeline()
+		cmds := make([]*redis.IntCmd, len(fullKeys))
+		for i, fullKey := range fullKeys {
+			cmds[i] = pipe.MemoryUsage(ctx, fullKey)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for i, fullKey := range fullKeys {
+			bytes, err := cmds[i].Result()
+			if err == redis.Nil {
+				// expired since scanned
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			key, _, _ := c.parseStorageKey(fullKey)
+			group := usageGroup(key)
+			if groups[group] == nil {
+				groups[group] = &goboilerplate.CacheUsage{Group: group}
+			}
+			groups[group].Keys++
+			groups[group].Bytes += bytes
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrap(err, "error getting memory usage from redis")
+		return
+	}
+
+	usage = make([]goboilerplate.CacheUsage, 0, len(groups))
+	for _, group := range groups {
+		usage = append(usage, *group)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Bytes != usage[j].Bytes {
+			return usage[i].Bytes > usage[j].Bytes
+		}
+		return usage[i].Group < usage[j].Group
+	})
+	return
+}
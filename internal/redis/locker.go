@@ -0,0 +1,245 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+const (
+	lockMinBackoff = 10 * time.Millisecond
+	lockMaxBackoff = 500 * time.Millisecond
+)
+
+// acquireScript sets the lock if it is free and returns the next fencing token, or 0 when the lock is held.
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// renewScript extends the lock only if it is still held by the same owner.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock only if it is still held by the same owner.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisLocker struct {
+	redisClient redis.UniversalClient
+	keyPrefix   string
+	timeout     time.Duration
+	fencing     bool
+}
+
+// NewRedisLocker is a constructor for a distributed lock using redis.
+// The fencing token counters are kept next to the locks, in the same cluster slot, and never expire. Both are out of
+// the scope of the Flush of a cacher with the same key prefix, so that the tokens stay monotonic.
+// Every call to redis is bounded by timeout, unless it's 0, so that a slow redis does not hold the lock holders.
+func NewRedisLocker(redisClient redis.UniversalClient, keyPrefix string, timeout time.Duration) goboilerplate.Locker {
+	return redisLocker{
+		redisClient: redisClient,
+		keyPrefix:   keyPrefix,
+		timeout:     timeout,
+		fencing:     true,
+	}
+}
+
+// NewRedisUnfencedLocker is a constructor for a distributed lock using redis, like NewRedisLocker but without fencing
+// tokens: the token of its locks is always 0, and nothing outlives them in redis. It suits the locks of unbounded
+// sets of keys, e.g. coalescing the loads of the cache keys, where a counter per key would never be deleted.
+func NewRedisUnfencedLocker(
+	redisClient redis.UniversalClient,
+	keyPrefix string,
+	timeout time.Duration,
+) goboilerplate.Locker {
+	return redisLocker{
+		redisClient: redisClient,
+		keyPrefix:   keyPrefix,
+		timeout:     timeout,
+	}
+}
+
+// getKeys returns the keys of the lock and of its fencing token counter, hash-tagged to share the same slot.
+func (l redisLocker) getKeys(key string) (lockKey string, tokenKey string) {
+	lockKey = internalKey(l.keyPrefix, "lock", "{"+key+"}")
+	return lockKey, lockKey + "###token"
+}
+
+func (l redisLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (goboilerplate.Lock, error) {
+	if ttl <= 0 {
+		return nil, errors.New("lock ttl must be positive")
+	}
+
+	ownerBytes := make([]byte, 16)
+	if _, err := rand.Read(ownerBytes); err != nil {
+		return nil, errors.Wrap(err, "error generating lock owner")
+	}
+	owner := hex.EncodeToString(ownerBytes)
+	lockKey, tokenKey := l.getKeys(key)
+
+	acquireCtx, cancel := withTimeout(ctx, l.timeout)
+	defer cancel()
+
+	var (
+		token    int64
+		acquired bool
+		err      error
+	)
+	if l.fencing {
+		keys := []string{lockKey, tokenKey}
+		token, err = acquireScript.Run(acquireCtx, l.redisClient, keys, owner, ttl.Milliseconds()).Int64()
+		acquired = token != 0
+	} else {
+		acquired, err = l.redisClient.SetNX(acquireCtx, lockKey, owner, ttl).Result()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error acquiring lock from redis")
+	}
+	if !acquired {
+		return nil, goboilerplate.ErrLockNotAcquired
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	lock := &redisLock{
+		redisClient: l.redisClient,
+		timeout:     l.timeout,
+		key:         lockKey,
+		owner:       owner,
+		token:       token,
+		ttl:         ttl,
+		lost:        make(chan struct{}),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	go lock.renew(renewCtx)
+
+	return lock, nil
+}
+
+// Acquire retries TryAcquire with an exponential backoff and full jitter, between lockMinBackoff and lockMaxBackoff.
+func (l redisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (goboilerplate.Lock, error) {
+	backoff := lockMinBackoff
+	for {
+		lock, err := l.TryAcquire(ctx, key, ttl)
+		if err != goboilerplate.ErrLockNotAcquired {
+			return lock, err
+		}
+
+		wait, err := rand.Int(rand.Reader, big.NewInt(int64(backoff)))
+		if err != nil {
+			return nil, errors.Wrap(err, "error generating lock backoff")
+		}
+
+		timer := time.NewTimer(time.Duration(wait.Int64()) + time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Wrapf(ctx.Err(), "error waiting for lock '%s'", key)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > lockMaxBackoff {
+			backoff = lockMaxBackoff
+		}
+	}
+}
+
+type redisLock struct {
+	redisClient redis.UniversalClient
+	timeout     time.Duration
+	key         string
+	owner       string
+	token       int64
+	ttl         time.Duration
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func (l *redisLock) Token() int64 {
+	return l.token
+}
+
+func (l *redisLock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *redisLock) markLost() {
+	l.lostOnce.Do(func() {
+		close(l.lost)
+	})
+}
+
+// renew extends the lock every third of its ttl, until released. The lock is lost once it is held by someone else,
+// or when it could not be renewed for a whole ttl.
+func (l *redisLock) renew(ctx context.Context) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewCtx, cancel := withTimeout(ctx, l.timeout)
+		ok, err := renewScript.Run(renewCtx, l.redisClient, []string{l.key}, l.owner, l.ttl.Milliseconds()).Int64()
+		cancel()
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			logrus.Warnf("Error renewing lock '%s': %+v", l.key, err)
+			if time.Since(renewed) >= l.ttl {
+				l.markLost()
+				return
+			}
+		case ok == 0:
+			logrus.Warnf("Lock '%s' was lost", l.key)
+			l.markLost()
+			return
+		default:
+			renewed = time.Now()
+		}
+	}
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	l.cancel()
+	<-l.done
+
+	ctx, cancel := withTimeout(ctx, l.timeout)
+	defer cancel()
+
+	if err := releaseScript.Run(ctx, l.redisClient, []string{l.key}, l.owner).Err(); err != nil {
+		return errors.Wrap(err, "error releasing lock from redis")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package redis_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/cache"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+func TestLocker(t *testing.T) {
+	ctx := context.Background()
+
+	server, redisClient := runMiniredis(t)
+	locker := redis.NewRedisLocker(redisClient, "test", time.Second)
+
+	lock, err := locker.TryAcquire(ctx, "some-job", 100*time.Millisecond)
+	require.NoError(t, err)
+
+	_, err = locker.TryAcquire(ctx, "some-job", time.Second)
+	require.Equal(t, goboilerplate.ErrLockNotAcquired, err)
+
+	// renewed past its ttl while held, every third of it
+	for i := 0; i < 3; i++ {
+		time.Sleep(50 * time.Millisecond)
+		server.FastForward(60 * time.Millisecond)
+	}
+	_, err = locker.TryAcquire(ctx, "some-job", time.Second)
+	require.Equal(t, goboilerplate.ErrLockNotAcquired, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = locker.Acquire(waitCtx, "some-job", time.Second)
+	require.EqualError(t, errors.Cause(err), context.DeadlineExceeded.Error())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = lock.Release(ctx)
+	}()
+
+	next, err := locker.Acquire(ctx, "some-job", time.Second)
+	require.NoError(t, err)
+	require.Greater(t, next.Token(), lock.Token())
+	require.NoError(t, next.Release(ctx))
+
+	t.Run("expired", func(t *testing.T) {
+		lock, err := locker.TryAcquire(ctx, "expired-job", 100*time.Millisecond)
+		require.NoError(t, err)
+		// stops the renewal, which deletes nothing once the lock expired
+		server.FastForward(100 * time.Millisecond)
+		require.NoError(t, lock.Release(ctx))
+
+		next, err := locker.TryAcquire(ctx, "expired-job", time.Second)
+		require.NoError(t, err)
+		require.Greater(t, next.Token(), lock.Token())
+		require.NoError(t, next.Release(ctx))
+	})
+
+	t.Run("tokens survive a flush", func(t *testing.T) {
+		lock, err := locker.TryAcquire(ctx, "flushed-job", time.Second)
+		require.NoError(t, err)
+
+		cacher := redis.NewRedisCacher(redisClient, goboilerplate.ExpiryConf{}, "test")
+		require.NoError(t, cacher.Flush(ctx))
+
+		_, err = locker.TryAcquire(ctx, "flushed-job", time.Second)
+		require.Equal(t, goboilerplate.ErrLockNotAcquired, err)
+		require.NoError(t, lock.Release(ctx))
+
+		next, err := locker.TryAcquire(ctx, "flushed-job", time.Second)
+		require.NoError(t, err)
+		require.Greater(t, next.Token(), lock.Token())
+		require.NoError(t, next.Release(ctx))
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		lock, err := locker.TryAcquire(ctx, "lost-job", 60*time.Millisecond)
+		require.NoError(t, err)
+		defer lock.Release(ctx)
+
+		require.NoError(t, redisClient.Del(ctx, "test:lock###{lost-job}").Err())
+
+		select {
+		case <-lock.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("lock should be lost")
+		}
+	})
+}
+
+func TestUnfencedLocker(t *testing.T) {
+	ctx := context.Background()
+
+	server, redisClient := runMiniredis(t)
+
+	cacher := redis.NewRedisCacher(redisClient, goboilerplate.ExpiryConf{}, "test")
+	locker := redis.NewRedisUnfencedLocker(redisClient, "test", time.Second)
+
+	t.Run("exclusive", func(t *testing.T) {
+		lock, err := locker.TryAcquire(ctx, "some-job", time.Second)
+		require.NoError(t, err)
+		require.Zero(t, lock.Token())
+
+		_, err = locker.TryAcquire(ctx, "some-job", time.Second)
+		require.Equal(t, goboilerplate.ErrLockNotAcquired, err)
+
+		require.NoError(t, lock.Release(ctx))
+		next, err := locker.TryAcquire(ctx, "some-job", time.Second)
+		require.NoError(t, err)
+		require.NoError(t, next.Release(ctx))
+	})
+
+	t.Run("nothing left after the loads", func(t *testing.T) {
+		loader := cache.NewLoader(cacher, locker, 100*time.Millisecond)
+		for _, key := range []string{"article:1", "article:2", "article:made-up"} {
+			_, err := loader.GetOrLoad(ctx, key, goboilerplate.DurationShort, func(ctx context.Context) (string, error) {
+				return "value", nil
+			})
+			require.NoError(t, err)
+		}
+		server.FastForward(time.Second)
+
+		for _, key := range server.Keys() {
+			require.False(t, strings.HasPrefix(key, "test:lock###"), key)
+			require.NotContains(t, key, "###token")
+		}
+	})
+}
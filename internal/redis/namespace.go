@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// namespaceVersionKeyPrefix prefixes the counters holding the current version of each namespace. They are never
+// flushed, so that a version is never reused.
+const namespaceVersionKeyPrefix = "ns-version###"
+
+type namespaceVersion struct {
+	version   int64
+	expiresAt time.Time
+}
+
+// namespaceVersions caches the namespace versions read from redis, sparing a round trip on every call.
+type namespaceVersions struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	versions map[string]namespaceVersion
+}
+
+func (v *namespaceVersions) get(namespace string) (version int64, ok bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cached, ok := v.versions[namespace]
+	if !ok || time.Now().After(cached.expiresAt) {
+		return 0, false
+	}
+	return cached.version, true
+}
+
+func (v *namespaceVersions) set(namespace string, version int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.versions[namespace] = namespaceVersion{
+		version:   version,
+		expiresAt: time.Now().Add(v.ttl),
+	}
+}
+
+// NewNamespacedRedisCacher is a constructor for caching using redis, where the keys formatted as "namespace:id"
+// belong to a versioned namespace and are stored as "prefix###ns###namespace###v<version>###namespace:id". Bumping
+// the version of a namespace invalidates all of its keys at once, leaving the old ones to expire.
+//
+// The versions are cached locally for versionCacheTTL, so the other instances see a bump after at most that long.
+// The returned cacher implements goboilerplate.NamespaceCacher besides the interfaces of NewRedisCacher.
+func NewNamespacedRedisCacher(
+	redisClient redis.UniversalClient,
+	expiryConf goboilerplate.ExpiryConf,
+	keyPrefix string,
+	versionCacheTTL time.Duration,
+) goboilerplate.NamespaceCacher {
+	c := NewRedisCacher(redisClient, expiryConf, keyPrefix).(redisCacher)
+	c.namespaces = &namespaceVersions{
+		ttl:      versionCacheTTL,
+		versions: make(map[string]namespaceVersion),
+	}
+	return c
+}
+
+func (c redisCacher) getNamespaceVersionKey(namespace string) string {
+	return c.getKey(namespaceVersionKeyPrefix + namespace)
+}
+
+func (c redisCacher) isNamespaceVersionKey(fullKey string) bool {
+	return c.namespaces != nil && strings.HasPrefix(fullKey, c.getKey(namespaceVersionKeyPrefix))
+}
+
+// namespaceVersion returns the current version of namespace, 0 if it has never been bumped.
+func (c redisCacher) namespaceVersion(ctx context.Context, namespace string) (version int64, err error) {
+	version, ok := c.namespaces.get(namespace)
+	if ok {
+		return
+	}
+
+	version, err = c.redisClient.Get(ctx, c.getNamespaceVersionKey(namespace)).Int64()
+	if err != nil && err != redis.Nil {
+		err = errors.Wrapf(err, "error getting version of namespace '%s' from redis", namespace)
+		return
+	}
+	err = nil
+
+	c.namespaces.set(namespace, version)
+	return
+}
+
+// getDataKey returns the redis key of key, within the current version of its namespace if namespaces are enabled.
+func (c redisCacher) getDataKey(ctx context.Context, key string) (string, error) {
+	if c.namespaces == nil {
+		return c.getKey(key), nil
+	}
+
+	namespace, ok := goboilerplate.KeyNamespace(key)
+	if !ok {
+		return c.getKey(key), nil
+	}
+
+	version, err := c.namespaceVersion(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	return c.getKey(fmt.Sprintf("ns###%s###v%d###%s", namespace, version, key)), nil
+}
+
+// BumpNamespace increments the version of namespace, invalidating all of its keys.
+func (c redisCacher) BumpNamespace(ctx context.Context, namespace string) (version int64, err error) {
+	if c.namespaces == nil {
+		err = errors.New("namespaces are not enabled")
+		return
+	}
+
+	version, err = c.redisClient.Incr(ctx, c.getNamespaceVersionKey(namespace)).Result()
+	if err != nil {
+		err = errors.Wrapf(err, "error bumping version of namespace '%s'", namespace)
+		return
+	}
+
+	c.namespaces.set(namespace, version)
+	return
+}
@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// gcraScript implements the generic cell rate algorithm: the key holds the theoretical arrival time (TAT) of the
+// next request, in microseconds of the redis clock, and a request is allowed when it arrives no earlier than the TAT
+// minus the burst. It returns {allowed, remaining, reset after, retry after}, with durations in microseconds.
+// See: https://brandur.org/rate-limiting
+var gcraScript = redis.NewScript(`
+if redis.replicate_commands then
+	redis.replicate_commands()
+end
+
+local emission_interval = tonumber(ARGV[1])
+local burst_offset = emission_interval * tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission_interval
+local diff = now - (new_tat - burst_offset)
+if diff < 0 then
+	return {0, 0, tat - now, -diff}
+end
+
+local reset_after = new_tat - now
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil(reset_after / 1000))
+return {1, math.floor(diff / emission_interval), reset_after, 0}
+`)
+
+type redisRateLimiter struct {
+	redisClient redis.UniversalClient
+	keyPrefix   string
+	timeout     time.Duration
+}
+
+// NewRedisRateLimiter is a constructor for a GCRA rate limiter using redis, storing a single key per limited key.
+// The redis clock is used, so that replicas with skewed clocks share the same limits. Every call is bounded by
+// timeout, unless it's 0, so that a slow redis does not hold the requests being limited.
+func NewRedisRateLimiter(
+	redisClient redis.UniversalClient,
+	keyPrefix string,
+	timeout time.Duration,
+) goboilerplate.RateLimiter {
+	return redisRateLimiter{
+		redisClient: redisClient,
+		keyPrefix:   keyPrefix,
+		timeout:     timeout,
+	}
+}
+
+// getKey returns the key of the bucket, out of the scope of the Flush of a cacher with the same key prefix.
+func (l redisRateLimiter) getKey(key string) string {
+	return internalKey(l.keyPrefix, "ratelimit", key)
+}
+
+// Allow counts a request against the limit of key. A limit without burst allows its whole rate at once.
+func (l redisRateLimiter) Allow(
+	ctx context.Context,
+	key string,
+	limit goboilerplate.RateLimit,
+) (result goboilerplate.RateLimitResult, err error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		err = errors.New("rate limit rate and period must be positive")
+		return
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Rate
+	}
+
+	emissionInterval := limit.Period.Microseconds() / int64(limit.Rate)
+	if emissionInterval <= 0 {
+		emissionInterval = 1
+	}
+
+	ctx, cancel := withTimeout(ctx, l.timeout)
+	defer cancel()
+
+	values, err := gcraScript.Run(ctx, l.redisClient, []string{l.getKey(key)}, emissionInterval, limit.Burst).Int64Slice()
+	if err != nil {
+		err = errors.Wrap(err, "error rate limiting with redis")
+		return
+	}
+	if len(values) != 4 {
+		err = errors.Errorf("unexpected rate limit reply %v", values)
+		return
+	}
+
+	result = goboilerplate.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}
+	return
+}
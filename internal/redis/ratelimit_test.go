@@ -0,0 +1,62 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	server, redisClient := runMiniredis(t)
+	// the limiter runs on the redis clock, frozen from now on
+	now := time.Now()
+	server.SetTime(now)
+
+	limiter := redis.NewRedisRateLimiter(redisClient, "test", time.Second)
+	limit := goboilerplate.RateLimit{Rate: 10, Period: time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx, "client", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+		require.Equal(t, 3, res.Limit)
+		require.Equal(t, 2-i, res.Remaining)
+	}
+
+	res, err := limiter.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+	require.Equal(t, 100*time.Millisecond, res.RetryAfter)
+	require.Equal(t, 300*time.Millisecond, res.ResetAfter)
+
+	// one more request every emission interval
+	server.SetTime(now.Add(res.RetryAfter))
+	res, err = limiter.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+
+	res, err = limiter.Allow(ctx, "client", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+
+	t.Run("expires once reset", func(t *testing.T) {
+		require.True(t, server.Exists("test:ratelimit###client"))
+
+		server.FastForward(300 * time.Millisecond)
+		require.False(t, server.Exists("test:ratelimit###client"))
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := limiter.Allow(ctx, "client", goboilerplate.RateLimit{Period: time.Second})
+		require.Error(t, err)
+	})
+}
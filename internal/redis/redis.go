@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v9"
 	"github.com/pkg/errors"
@@ -14,9 +15,14 @@ type redisCacher struct {
 	redisClient redis.UniversalClient
 	expiryConf  goboilerplate.ExpiryConf
 	keyPrefix   string
+	cluster     bool
+	// namespaces is nil unless built with NewNamespacedRedisCacher.
+	namespaces *namespaceVersions
 }
 
-// NewRedisCacher is a constructor for caching using redis
+// NewRedisCacher is a constructor for caching using redis.
+// The returned cacher also implements goboilerplate.BatchCacher, goboilerplate.ProgressFlusher,
+// goboilerplate.TagCacher and goboilerplate.CacheInspector.
 func NewRedisCacher(redisClient redis.UniversalClient, expiryConf goboilerplate.ExpiryConf, keyPrefix string) goboilerplate.Cacher {
 	expiryConf.Set()
 
@@ -24,9 +30,17 @@ func NewRedisCacher(redisClient redis.UniversalClient, expiryConf goboilerplate.
 		redisClient: redisClient,
 		expiryConf:  expiryConf,
 		keyPrefix:   keyPrefix,
+		cluster:     isCluster(redisClient),
 	}
 }
 
+// isCluster tells whether the keys are sharded by slot. A cluster client given its slots, e.g. the failover one routing
+// the reads to the replicas of a sentinel master, is not.
+func isCluster(redisClient redis.UniversalClient) bool {
+	clusterClient, ok := redisClient.(*redis.ClusterClient)
+	return ok && clusterClient.Options().ClusterSlots == nil
+}
+
 func (c redisCacher) getKey(key string) string {
 	if c.keyPrefix == "" {
 		return key
@@ -34,8 +48,30 @@ func (c redisCacher) getKey(key string) string {
 	return fmt.Sprintf("%s###%s", c.keyPrefix, key)
 }
 
+// withTimeout bounds ctx with timeout, unless timeout is 0.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// internalKey returns the key of kind, e.g. "lock", for the components sharing the cacher's key prefix. It is kept
+// apart from the "prefix###" keys of the cacher, so that Flush does not delete it.
+func internalKey(keyPrefix string, kind string, key string) string {
+	if keyPrefix == "" {
+		return kind + "###" + key
+	}
+	return keyPrefix + ":" + kind + "###" + key
+}
+
 func (c redisCacher) Get(ctx context.Context, key string) (value string, err error) {
-	value, err = c.redisClient.Get(ctx, c.getKey(key)).Result()
+	dataKey, err := c.getDataKey(ctx, key)
+	if err != nil {
+		return
+	}
+
+	value, err = c.redisClient.Get(ctx, dataKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			err = goboilerplate.ErrNotFound
@@ -49,11 +85,16 @@ func (c redisCacher) Get(ctx context.Context, key string) (value string, err err
 }
 
 func (c redisCacher) Set(ctx context.Context, key string, value string, expiration goboilerplate.ExpiryDuration) (err error) {
+	dataKey, err := c.getDataKey(ctx, key)
+	if err != nil {
+		return
+	}
+
 	err = c.redisClient.Set(
 		ctx,
-		c.getKey(key),
+		dataKey,
 		value,
-		c.expiryConf[expiration],
+		c.expiryConf.TTL(expiration),
 	).Err()
 	if err != nil {
 		err = errors.Wrap(err, "error setting data to redis")
@@ -64,8 +105,13 @@ func (c redisCacher) Set(ctx context.Context, key string, value string, expirati
 }
 
 func (c redisCacher) Del(ctx context.Context, key string) (err error) {
+	dataKey, err := c.getDataKey(ctx, key)
+	if err != nil {
+		return
+	}
+
 	var count int64
-	count, err = c.redisClient.Del(ctx, c.getKey(key)).Result()
+	count, err = c.redisClient.Del(ctx, dataKey).Result()
 	if err != nil {
 		err = errors.Wrap(err, "error deleting data from redis")
 		return
@@ -76,13 +122,3 @@ func (c redisCacher) Del(ctx context.Context, key string) (err error) {
 	}
 	return
 }
-
-func (s redisCacher) Flush(ctx context.Context) (err error) {
-	err = s.redisClient.FlushDB(ctx).Err()
-	if err != nil {
-		err = errors.Wrap(err, "error flushing redis cache")
-		return
-	}
-
-	return
-}
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	_redis "github.com/go-redis/redis/v9"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -86,6 +87,16 @@ func tryPing(db _redis.UniversalClient) (err error) {
 	return
 }
 
+// runMiniredis runs an in-process redis for the tests needing no redis service.
+func runMiniredis(t *testing.T) (server *miniredis.Miniredis, redisClient _redis.UniversalClient) {
+	server = miniredis.RunT(t)
+	redisClient = _redis.NewClient(&_redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		require.NoError(t, redisClient.Close())
+	})
+	return
+}
+
 func (s *redisTestSuite) TearDownSuite() {
 	s.redisClient.FlushDB(context.Background())
 
@@ -221,3 +232,169 @@ func (s *redisTestSuite) TestFlush() {
 	require.Error(t, err)
 	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
 }
+
+func (s *redisTestSuite) TestBatch() {
+	t := s.T()
+	ctx := context.Background()
+
+	cacher := redis.NewRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test")
+
+	results := goboilerplate.MSet(ctx, cacher, []goboilerplate.KeyValue{
+		{Key: "batch-1", Value: "v1"},
+		{Key: "batch-2", Value: "v2"},
+		{Key: "{batch}-3", Value: "v3"},
+	}, goboilerplate.DurationShort)
+	require.Len(t, results, 3)
+	for _, res := range results {
+		require.NoError(t, res.Err)
+	}
+
+	results = goboilerplate.MGet(ctx, cacher, "batch-1", "batch-missing", "{batch}-3", "batch-2")
+	require.Equal(t, []goboilerplate.BatchResult{
+		{Key: "batch-1", Value: "v1"},
+		{Key: "batch-missing", Err: goboilerplate.ErrNotFound},
+		{Key: "{batch}-3", Value: "v3"},
+		{Key: "batch-2", Value: "v2"},
+	}, results)
+
+	results = goboilerplate.MDel(ctx, cacher, "batch-1", "batch-missing")
+	require.Equal(t, []goboilerplate.BatchResult{
+		{Key: "batch-1"},
+		{Key: "batch-missing", Err: goboilerplate.ErrNotFound},
+	}, results)
+}
+
+func (s *redisTestSuite) TestFlushWithProgress() {
+	t := s.T()
+	ctx := context.Background()
+
+	cacher := redis.NewRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test*flush")
+	otherCacher := redis.NewRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test-other")
+
+	for i := 0; i < 2500; i++ {
+		require.NoError(t, cacher.Set(ctx, fmt.Sprintf("k%d", i), "v", goboilerplate.DurationShort))
+	}
+	require.NoError(t, otherCacher.Set(ctx, "k1", "v1", goboilerplate.DurationShort))
+	require.NoError(t, s.redisClient.Set(ctx, "test-unprefixed", "v", time.Minute).Err())
+
+	var reported int64
+	deleted, err := cacher.(goboilerplate.ProgressFlusher).FlushWithProgress(ctx, func(deleted int64) {
+		reported = deleted
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2500), deleted)
+	require.Equal(t, deleted, reported)
+
+	_, err = cacher.Get(ctx, "k1")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+
+	res, err := otherCacher.Get(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, "v1", res)
+
+	require.NoError(t, s.redisClient.Get(ctx, "test-unprefixed").Err())
+
+	t.Run("without key prefix", func(t *testing.T) {
+		err := redis.NewRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "").Flush(ctx)
+		require.Error(t, err)
+		require.NoError(t, s.redisClient.Get(ctx, "test-unprefixed").Err())
+	})
+}
+
+func (s *redisTestSuite) TestInvalidateTags() {
+	t := s.T()
+	ctx := context.Background()
+
+	cacher := redis.NewRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test").(goboilerplate.TagCacher)
+
+	article1Tags := []string{"author:42", "category:news"}
+	require.NoError(t, cacher.SetWithTags(ctx, "article:1", "v1", goboilerplate.DurationShort, article1Tags...))
+	require.NoError(t, cacher.SetWithTags(ctx, "article:2", "v2", goboilerplate.DurationLong, "author:7"))
+	require.NoError(t, cacher.SetWithTags(ctx, "articles:news", "v3", goboilerplate.DurationLong, "category:news"))
+
+	ttl, err := s.redisClient.PTTL(ctx, "test###tag###category:news").Result()
+	require.NoError(t, err)
+	require.Greater(t, ttl, time.Minute)
+
+	require.NoError(t, cacher.InvalidateTags(ctx, "author:42"))
+
+	_, err = cacher.Get(ctx, "article:1")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "article:2")
+	require.NoError(t, err)
+	_, err = cacher.Get(ctx, "articles:news")
+	require.NoError(t, err)
+
+	require.NoError(t, cacher.InvalidateTags(ctx, "category:news", "author:7"))
+
+	_, err = cacher.Get(ctx, "article:2")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "articles:news")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+}
+
+func (s *redisTestSuite) TestBumpNamespace() {
+	t := s.T()
+	ctx := context.Background()
+
+	require.NoError(t, s.redisClient.Del(ctx, "test###ns-version###article").Err())
+
+	cacher := redis.NewNamespacedRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test", time.Minute)
+	otherInstance := redis.NewNamespacedRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test", 10*time.Millisecond)
+
+	require.NoError(t, cacher.Set(ctx, "article:1", "v1", goboilerplate.DurationShort))
+	require.NoError(t, cacher.Set(ctx, "author:1", "v1", goboilerplate.DurationShort))
+	require.NoError(t, cacher.Set(ctx, "plain", "v1", goboilerplate.DurationShort))
+
+	res, err := s.redisClient.Get(ctx, "test###ns###article###v0###article:1").Result()
+	require.NoError(t, err)
+	require.Equal(t, "v1", res)
+
+	_, err = otherInstance.Get(ctx, "article:1")
+	require.NoError(t, err)
+
+	version, err := cacher.BumpNamespace(ctx, "article")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), version)
+
+	_, err = cacher.Get(ctx, "article:1")
+	require.EqualError(t, errors.Cause(err), goboilerplate.ErrNotFound.Error())
+	_, err = cacher.Get(ctx, "author:1")
+	require.NoError(t, err)
+	_, err = cacher.Get(ctx, "plain")
+	require.NoError(t, err)
+
+	// the other instance sees the bump once its cached version expires
+	require.Eventually(t, func() bool {
+		_, err := otherInstance.Get(ctx, "article:1")
+		return errors.Cause(err) == goboilerplate.ErrNotFound
+	}, time.Second, 10*time.Millisecond)
+
+	// flushing does not reset the versions
+	require.NoError(t, cacher.Flush(ctx))
+	version, err = s.redisClient.Get(ctx, "test###ns-version###article").Int64()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), version)
+}
+
+func (s *redisTestSuite) TestMemoryUsage() {
+	t := s.T()
+	ctx := context.Background()
+
+	cacher := redis.NewNamespacedRedisCacher(s.redisClient, goboilerplate.ExpiryConf{}, "test-usage", time.Minute)
+	require.NoError(t, cacher.Flush(ctx))
+
+	require.NoError(t, cacher.Set(ctx, "article:1", "v1", goboilerplate.DurationShort))
+	require.NoError(t, cacher.Set(ctx, "article:2", "v2", goboilerplate.DurationShort))
+	require.NoError(t, goboilerplate.SetWithTags(ctx, cacher, "plain", "v1", goboilerplate.DurationShort, "author:1"))
+
+	usage, err := cacher.(goboilerplate.CacheInspector).MemoryUsage(ctx)
+	require.NoError(t, err)
+
+	keys := make(map[string]int64)
+	for _, group := range usage {
+		require.Greater(t, group.Bytes, int64(0))
+		keys[group.Group] = group.Keys
+	}
+	require.Equal(t, map[string]int64{"article": 2, "default": 1, "tag": 1}, keys)
+}
@@ -0,0 +1,39 @@
+package redis
+
+import "strings"
+
+const slotCount = 16384
+
+// crc16Table is the lookup table of CRC16/XMODEM, the checksum used by redis cluster to compute key slots.
+var crc16Table = func() (table [256]uint16) {
+	for i := range table {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return
+}()
+
+func crc16(s string) (crc uint16) {
+	for i := 0; i < len(s); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^s[i]]
+	}
+	return
+}
+
+// hashSlot returns the redis cluster slot of the key, honouring {hash tags}.
+// See: https://redis.io/docs/reference/cluster-spec/#hash-tags
+func hashSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % slotCount
+}
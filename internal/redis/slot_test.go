@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashSlot(t *testing.T) {
+	// the expected slots are the ones of CLUSTER KEYSLOT
+	tests := map[string]int{
+		"123456789":            12739,
+		"foo":                  12182,
+		"bar":                  5061,
+		"user1000":             3443,
+		"{user1000}.following": 3443,
+		"{user1000}.followers": 3443,
+		"foo{}{bar}":           8363,
+		"foo{{bar}}zap":        4015,
+		"article:1":            9128,
+		"article:2":            5067,
+	}
+
+	for key, expected := range tests {
+		t.Run(key, func(t *testing.T) {
+			require.Equal(t, expected, hashSlot(key))
+		})
+	}
+}
+
+func TestGroupBySlot(t *testing.T) {
+	dataKeys := []string{"article:1", "{user1000}.following", "article:2", "user1000", "{article:1}.tags"}
+
+	t.Run("cluster", func(t *testing.T) {
+		c := redisCacher{cluster: true}
+		require.Equal(t, [][]int{{0, 4}, {1, 3}, {2}}, c.groupBySlot(dataKeys))
+	})
+
+	t.Run("single node", func(t *testing.T) {
+		c := redisCacher{}
+		require.Equal(t, [][]int{{0, 1, 2, 3, 4}}, c.groupBySlot(dataKeys))
+	})
+}
+
+func TestIsCluster(t *testing.T) {
+	clusterClient := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"localhost:7000"}})
+	defer clusterClient.Close()
+	require.True(t, isCluster(clusterClient))
+
+	failoverClusterClient := redis.NewFailoverClusterClient(&redis.FailoverOptions{
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{"localhost:26379"},
+	})
+	defer failoverClusterClient.Close()
+	require.False(t, isCluster(failoverClusterClient))
+
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer client.Close()
+	require.False(t, isCluster(client))
+}
@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+// The scripts below are run with EVAL, since EVALSHA could not fall back to EVAL within a pipeline.
+
+// extendTTLScript sets the TTL of the key, unless it already expires later.
+var extendTTLScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < tonumber(ARGV[1]) then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// popMembersScript returns the members of the set and deletes it atomically.
+var popMembersScript = redis.NewScript(`
+local members = redis.call("SMEMBERS", KEYS[1])
+redis.call("DEL", KEYS[1])
+return members
+`)
+
+// getTagKey returns the key of the set indexing the keys tagged with tag.
+func (c redisCacher) getTagKey(tag string) string {
+	return c.getKey("tag###" + tag)
+}
+
+// SetWithTags sets the value and adds the key to the index of each tag. The indexes expire with the longest-lived
+// entry they contain.
+func (c redisCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) (err error) {
+	ttl := c.expiryConf.TTL(expiration)
+	fullKey, err := c.getDataKey(ctx, key)
+	if err != nil {
+		return
+	}
+
+	_, err = c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fullKey, value, ttl)
+		for _, tag := range tags {
+			tagKey := c.getTagKey(tag)
+			pipe.SAdd(ctx, tagKey, fullKey)
+			if ttl > 0 {
+				extendTTLScript.Eval(ctx, pipe, []string{tagKey}, ttl.Milliseconds())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrap(err, "error setting tagged data to redis")
+		return
+	}
+
+	return
+}
+
+// InvalidateTags deletes every key having any of the tags.
+func (c redisCacher) InvalidateTags(ctx context.Context, tags ...string) (err error) {
+	cmds, err := c.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			popMembersScript.Eval(ctx, pipe, []string{c.getTagKey(tag)})
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrap(err, "error getting tagged keys from redis")
+		return
+	}
+
+	var keys []string
+	for _, cmd := range cmds {
+		members, err := cmd.(*redis.Cmd).StringSlice()
+		if err != nil {
+			return errors.Wrap(err, "error getting tagged keys from redis")
+		}
+		keys = append(keys, members...)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	_, err = unlink(ctx, c.redisClient, keys)
+	if err != nil {
+		err = errors.Wrap(err, "error deleting tagged data from redis")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,331 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+)
+
+const (
+	invalidationOpDel   = "del"
+	invalidationOpFlush = "flush"
+)
+
+// invalidation is the message broadcast to the other instances whenever a local entry becomes stale.
+type invalidation struct {
+	Origin string `json:"origin"`
+	Op     string `json:"op"`
+	Key    string `json:"key,omitempty"`
+}
+
+type tieredCacher struct {
+	local       goboilerplate.Cacher
+	remote      goboilerplate.Cacher
+	redisClient redis.UniversalClient
+	channel     string
+	origin      string
+}
+
+// NewTieredCacher is a constructor for a two-tier cacher, putting the local cacher in front of the remote one.
+// Every Set, Del and Flush is broadcast to the other instances through the redis pub/sub channel, so that they
+// evict their local copies. The subscription lives until ctx is done.
+//
+// Invalidations published while an instance is disconnected are lost, so the local cacher should be configured
+// with a short expiry.
+//
+// The returned cacher implements goboilerplate.BatchCacher, goboilerplate.ProgressFlusher, goboilerplate.TagCacher
+// and goboilerplate.NamespaceCacher on top of remote, see the helpers of the same name for the fallbacks.
+func NewTieredCacher(
+	ctx context.Context,
+	redisClient redis.UniversalClient,
+	local, remote goboilerplate.Cacher,
+	channel string,
+) (goboilerplate.Cacher, error) {
+	originBytes := make([]byte, 8)
+	if _, err := rand.Read(originBytes); err != nil {
+		return nil, errors.Wrap(err, "error generating cacher origin")
+	}
+
+	c := tieredCacher{
+		local:       local,
+		remote:      remote,
+		redisClient: redisClient,
+		channel:     channel,
+		origin:      hex.EncodeToString(originBytes),
+	}
+
+	pubsub := redisClient.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, errors.Wrapf(err, "error subscribing to '%s'", channel)
+	}
+
+	go c.listen(ctx, pubsub)
+
+	return c, nil
+}
+
+func (c tieredCacher) listen(ctx context.Context, pubsub *redis.PubSub) {
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			logrus.Warnf("Error closing subscription to '%s': %+v", c.channel, err)
+		}
+	}()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			c.handleInvalidation(ctx, msg.Payload)
+		}
+	}
+}
+
+func (c tieredCacher) handleInvalidation(ctx context.Context, payload string) {
+	var inv invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		logrus.Warnf("Error unmarshalling cache invalidation '%s': %+v", payload, err)
+		return
+	}
+	if inv.Origin == c.origin {
+		return
+	}
+
+	var err error
+	switch inv.Op {
+	case invalidationOpDel:
+		err = c.local.Del(ctx, inv.Key)
+	case invalidationOpFlush:
+		err = c.local.Flush(ctx)
+	default:
+		logrus.Warnf("Unknown cache invalidation op '%s'", inv.Op)
+		return
+	}
+	if err != nil && !errors.Is(err, goboilerplate.ErrNotFound) {
+		logrus.Warnf("Error invalidating local cache: %+v", err)
+	}
+}
+
+func (c tieredCacher) publish(ctx context.Context, op string, key string) {
+	payload, err := json.Marshal(invalidation{
+		Origin: c.origin,
+		Op:     op,
+		Key:    key,
+	})
+	if err != nil {
+		logrus.Warnf("Error marshalling cache invalidation: %+v", err)
+		return
+	}
+
+	if err := c.redisClient.Publish(ctx, c.channel, payload).Err(); err != nil {
+		logrus.Warnf("Error publishing cache invalidation to '%s': %+v", c.channel, err)
+	}
+}
+
+func (c tieredCacher) Get(ctx context.Context, key string) (value string, err error) {
+	value, err = c.local.Get(ctx, key)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, goboilerplate.ErrNotFound) {
+		logrus.Warnf("Error getting '%s' from local cache: %+v", key, err)
+	}
+
+	value, err = c.remote.Get(ctx, key)
+	if err != nil {
+		return
+	}
+
+	if err := c.local.Set(ctx, key, value, goboilerplate.DurationShort); err != nil {
+		logrus.Warnf("Error setting '%s' to local cache: %+v", key, err)
+	}
+	return
+}
+
+func (c tieredCacher) Set(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+) (err error) {
+	err = c.remote.Set(ctx, key, value, expiration)
+	if err != nil {
+		_ = c.local.Del(ctx, key)
+		return
+	}
+
+	if err := c.local.Set(ctx, key, value, expiration); err != nil {
+		logrus.Warnf("Error setting '%s' to local cache: %+v", key, err)
+	}
+	c.publish(ctx, invalidationOpDel, key)
+	return
+}
+
+func (c tieredCacher) Del(ctx context.Context, key string) (err error) {
+	_ = c.local.Del(ctx, key)
+
+	err = c.remote.Del(ctx, key)
+	if err != nil && !errors.Is(err, goboilerplate.ErrNotFound) {
+		return
+	}
+
+	c.publish(ctx, invalidationOpDel, key)
+	return
+}
+
+func (c tieredCacher) Flush(ctx context.Context) (err error) {
+	err = c.remote.Flush(ctx)
+	if err != nil {
+		return
+	}
+
+	if err := c.local.Flush(ctx); err != nil {
+		logrus.Warnf("Error flushing local cache: %+v", err)
+	}
+	c.publish(ctx, invalidationOpFlush, "")
+	return
+}
+
+// MGet gets the keys from the local cacher, and the missing ones from the remote cacher in a single batch.
+func (c tieredCacher) MGet(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	results = goboilerplate.MGet(ctx, c.local, keys...)
+
+	var missing []string
+	var indexes []int
+	for i, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		if !errors.Is(result.Err, goboilerplate.ErrNotFound) {
+			logrus.Warnf("Error getting '%s' from local cache: %+v", result.Key, result.Err)
+		}
+		missing = append(missing, result.Key)
+		indexes = append(indexes, i)
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	for j, result := range goboilerplate.MGet(ctx, c.remote, missing...) {
+		results[indexes[j]] = result
+		if result.Err != nil {
+			continue
+		}
+		if err := c.local.Set(ctx, result.Key, result.Value, goboilerplate.DurationShort); err != nil {
+			logrus.Warnf("Error setting '%s' to local cache: %+v", result.Key, err)
+		}
+	}
+	return
+}
+
+func (c tieredCacher) MSet(
+	ctx context.Context,
+	items []goboilerplate.KeyValue,
+	expiration goboilerplate.ExpiryDuration,
+) (results []goboilerplate.BatchResult) {
+	results = goboilerplate.MSet(ctx, c.remote, items, expiration)
+	for _, result := range results {
+		if result.Err != nil {
+			_ = c.local.Del(ctx, result.Key)
+			continue
+		}
+
+		if err := c.local.Set(ctx, result.Key, result.Value, expiration); err != nil {
+			logrus.Warnf("Error setting '%s' to local cache: %+v", result.Key, err)
+		}
+		c.publish(ctx, invalidationOpDel, result.Key)
+	}
+	return
+}
+
+func (c tieredCacher) MDel(ctx context.Context, keys ...string) (results []goboilerplate.BatchResult) {
+	_ = goboilerplate.MDel(ctx, c.local, keys...)
+
+	results = goboilerplate.MDel(ctx, c.remote, keys...)
+	for _, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, goboilerplate.ErrNotFound) {
+			continue
+		}
+		c.publish(ctx, invalidationOpDel, result.Key)
+	}
+	return
+}
+
+func (c tieredCacher) FlushWithProgress(
+	ctx context.Context,
+	progress goboilerplate.FlushProgressFunc,
+) (deleted int64, err error) {
+	deleted, err = goboilerplate.FlushWithProgress(ctx, c.remote, progress)
+	if err != nil {
+		return
+	}
+
+	if err := c.local.Flush(ctx); err != nil {
+		logrus.Warnf("Error flushing local cache: %+v", err)
+	}
+	c.publish(ctx, invalidationOpFlush, "")
+	return
+}
+
+// BumpNamespace bumps the namespace on the remote cacher. Since local entries are not versioned, every instance
+// flushes its whole local cacher.
+func (c tieredCacher) BumpNamespace(ctx context.Context, namespace string) (version int64, err error) {
+	version, err = goboilerplate.BumpNamespace(ctx, c.remote, namespace)
+	if err != nil {
+		return
+	}
+
+	if err := c.local.Flush(ctx); err != nil {
+		logrus.Warnf("Error flushing local cache: %+v", err)
+	}
+	c.publish(ctx, invalidationOpFlush, "")
+	return
+}
+
+// SetWithTags sets the tagged value to the remote cacher, which must implement goboilerplate.TagCacher.
+func (c tieredCacher) SetWithTags(
+	ctx context.Context,
+	key string,
+	value string,
+	expiration goboilerplate.ExpiryDuration,
+	tags ...string,
+) (err error) {
+	err = goboilerplate.SetWithTags(ctx, c.remote, key, value, expiration, tags...)
+	if err != nil {
+		_ = c.local.Del(ctx, key)
+		return
+	}
+
+	if err := c.local.Set(ctx, key, value, expiration); err != nil {
+		logrus.Warnf("Error setting '%s' to local cache: %+v", key, err)
+	}
+	c.publish(ctx, invalidationOpDel, key)
+	return
+}
+
+// InvalidateTags invalidates the tags on the remote cacher. Since local entries are filled without their tags,
+// every instance flushes its whole local cacher.
+func (c tieredCacher) InvalidateTags(ctx context.Context, tags ...string) (err error) {
+	err = goboilerplate.InvalidateTags(ctx, c.remote, tags...)
+	if err != nil {
+		return
+	}
+
+	if err := c.local.Flush(ctx); err != nil {
+		logrus.Warnf("Error flushing local cache: %+v", err)
+	}
+	c.publish(ctx, invalidationOpFlush, "")
+	return
+}
@@ -0,0 +1,69 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	goboilerplate "github.com/kurio/boilerplate-go"
+	"github.com/kurio/boilerplate-go/internal/memory"
+	"github.com/kurio/boilerplate-go/internal/redis"
+)
+
+func TestTieredCacher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, redisClient := runMiniredis(t)
+	remote := redis.NewRedisCacher(redisClient, goboilerplate.ExpiryConf{}, "test")
+
+	newTieredCacher := func() goboilerplate.Cacher {
+		local := memory.NewMemoryCacher(goboilerplate.ExpiryConf{}, 0, 0)
+		cacher, err := redis.NewTieredCacher(ctx, redisClient, local, remote, "test###invalidation")
+		require.NoError(t, err)
+		return cacher
+	}
+
+	instance1 := newTieredCacher()
+	instance2 := newTieredCacher()
+
+	require.NoError(t, instance1.Set(ctx, "tiered", "v1", goboilerplate.DurationShort))
+
+	res, err := instance2.Get(ctx, "tiered")
+	require.NoError(t, err)
+	require.Equal(t, "v1", res)
+
+	t.Run("set invalidates other instances", func(t *testing.T) {
+		require.NoError(t, instance1.Set(ctx, "tiered", "v2", goboilerplate.DurationShort))
+
+		require.Eventually(t, func() bool {
+			res, err := instance2.Get(ctx, "tiered")
+			return err == nil && res == "v2"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("del invalidates other instances", func(t *testing.T) {
+		require.NoError(t, instance1.Del(ctx, "tiered"))
+
+		require.Eventually(t, func() bool {
+			_, err := instance2.Get(ctx, "tiered")
+			return errors.Cause(err) == goboilerplate.ErrNotFound
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("flush invalidates other instances", func(t *testing.T) {
+		require.NoError(t, instance1.Set(ctx, "tiered", "v3", goboilerplate.DurationShort))
+		_, err := instance2.Get(ctx, "tiered")
+		require.NoError(t, err)
+
+		require.NoError(t, instance1.Flush(ctx))
+
+		require.Eventually(t, func() bool {
+			_, err := instance2.Get(ctx, "tiered")
+			return errors.Cause(err) == goboilerplate.ErrNotFound
+		}, time.Second, 10*time.Millisecond)
+	})
+}
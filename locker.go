@@ -0,0 +1,26 @@
+package goboilerplate
+
+import (
+	"context"
+	"time"
+)
+
+// Lock is a held distributed lock, renewed automatically until released.
+type Lock interface {
+	// Token is the fencing token of the lock, greater than the token of every previous holder of the same key.
+	// Pass it along the writes guarded by the lock, so that the storage can reject the ones of a stale holder.
+	// It is 0 when the locker gives no fencing tokens.
+	Token() int64
+	// Lost is closed when the lock could not be renewed before expiring, after which the work must be stopped.
+	Lost() <-chan struct{}
+	// Release stops the renewal and releases the lock.
+	Release(ctx context.Context) error
+}
+
+// Locker is the interface of a distributed lock shared between replicas.
+type Locker interface {
+	// TryAcquire acquires the lock of key for ttl without waiting, returning ErrLockNotAcquired when it is held.
+	TryAcquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+	// Acquire waits with backoff until the lock of key is acquired or ctx is done.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
@@ -0,0 +1,31 @@
+package goboilerplate
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit allows Rate requests per Period, with bursts of up to Burst requests.
+type RateLimit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// RateLimitResult is the outcome of a rate limited request.
+type RateLimitResult struct {
+	Allowed bool
+	// Limit is the maximum number of requests allowed at once, i.e. the burst.
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the limit is fully available again.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, 0 when Allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter is the interface of a rate limiter shared between replicas.
+type RateLimiter interface {
+	// Allow counts a request against the limit of key.
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}